@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"time"
+)
+
+// New names of the last GenerateNewNames call, reused while the folder and the steps stay the same
+type nameCache struct {
+	steps string                  // Folder and steps the names were generated with
+	names map[nameCacheKey]string // New name of each file
+}
+
+// A file as seen by the steps, the index only matters to steps that number the files
+type nameCacheKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+	index   int
+}
+
+// Get the cached names for the current steps, a change of the folder or of any step drops them
+func (rp *RenamerProcessor) cachedNames() map[nameCacheKey]string {
+	data, err := json.Marshal(rp.Rules)
+	if err != nil {
+		return nil
+	}
+	steps := rp.FolderPath + "\x00" + string(data)
+	if rp.names.steps != steps {
+		rp.names = nameCache{steps: steps}
+	}
+	return rp.names.names
+}
+
+// Check whether a step uses the position of the file
+func (rp *RenamerProcessor) usesIndex() bool {
+	return slices.ContainsFunc(rp.Rules, func(rule RenameRule) bool {
+		return rule.Type == "Number" || rule.Type == "Template"
+	})
+}
+
+// Result of looking up a path on disk
+type statResult struct {
+	info os.FileInfo
+	err  error
+}
+
+// Look up a path on disk, the results are cached while checkConflicts checks the batch
+func (rp *RenamerProcessor) lstat(path string) (os.FileInfo, error) {
+	// A stopped preview is thrown away, the remaining paths are not looked up
+	if rp.stopped() {
+		return nil, errPreviewStopped
+	}
+	if rp.stats == nil {
+		return os.Lstat(path)
+	}
+	if result, ok := rp.stats[path]; ok {
+		return result.info, result.err
+	}
+	info, err := os.Lstat(path)
+	rp.stats[path] = statResult{info, err}
+	return info, err
+}
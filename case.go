@@ -0,0 +1,129 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Case conversions supported by the case step
+var CaseModes = []string{"lower", "UPPER", "Title", "snake_case", "kebab-case", "camelCase"}
+
+// Words kept in lower case by Title Case unless they start the name
+const DefaultSmallWords = "a, an, and, of, the, in, on, or, to"
+
+// Convert the text to the given case, smallWords are only used by Title Case
+func ConvertCase(text, mode string, smallWords []string) string {
+	switch mode {
+	case "lower":
+		return strings.ToLower(text)
+	case "UPPER":
+		return strings.ToUpper(text)
+	case "Title":
+		return titleCase(text, smallWords)
+	case "snake_case":
+		return strings.ToLower(strings.Join(splitWords(text), "_"))
+	case "kebab-case":
+		return strings.ToLower(strings.Join(splitWords(text), "-"))
+	case "camelCase":
+		words := splitWords(text)
+		for i, word := range words {
+			word = strings.ToLower(word)
+			if i > 0 {
+				word = capitalize(word)
+			}
+			words[i] = word
+		}
+		return strings.Join(words, "")
+	}
+	return text
+}
+
+// Parse a comma separated list of small words
+func ParseSmallWords(list string) []string {
+	var words []string
+	for _, word := range strings.Split(list, ",") {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// Capitalize every word and keep the separators, small words stay lower case except the first word
+func titleCase(text string, smallWords []string) string {
+	var result strings.Builder
+	var word []rune
+	first := true
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		lower := strings.ToLower(string(word))
+		if first || !slices.Contains(smallWords, lower) {
+			lower = capitalize(lower)
+		}
+		result.WriteString(lower)
+		word = word[:0]
+		first = false
+	}
+	for _, r := range text {
+		if isWordRune(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		result.WriteRune(r)
+	}
+	flush()
+	return result.String()
+}
+
+// Split text into words at separators and at camelCase boundaries, e.g. "myHTTPFile-v2" → my, HTTP, File, v2
+func splitWords(text string) []string {
+	var words []string
+	// Apostrophes do not split words, "don't" becomes "dont"
+	runes := []rune(strings.ReplaceAll(text, "'", ""))
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		// Start a new word at "aB" and at the last capital of "ABc"
+		prev := runes[i-1]
+		lowerToUpper := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		acronymEnd := unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// Check whether a rune belongs to a word for Title Case
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\''
+}
+
+// Make the first letter upper case
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if size == 0 {
+		return word
+	}
+	return string(unicode.ToUpper(r)) + word[size:]
+}
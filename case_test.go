@@ -0,0 +1,46 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"myHTTPFile-v2", []string{"my", "HTTP", "File", "v2"}},
+		{"hello_world  again", []string{"hello", "world", "again"}},
+		{"don't stop", []string{"dont", "stop"}},
+		{"IMG2024Trip", []string{"IMG2024", "Trip"}},
+		{"Café crème", []string{"Café", "crème"}},
+		{"--", nil},
+	}
+	for _, test := range tests {
+		if words := splitWords(test.text); !slices.Equal(words, test.want) {
+			t.Errorf("%q: got %q, want %q", test.text, words, test.want)
+		}
+	}
+}
+
+func TestConvertCase(t *testing.T) {
+	smallWords := ParseSmallWords(DefaultSmallWords)
+	tests := []struct {
+		text, mode, want string
+	}{
+		{"Holiday Photos", "lower", "holiday photos"},
+		{"holiday photos", "UPPER", "HOLIDAY PHOTOS"},
+		{"the lord of the rings", "Title", "The Lord of the Rings"},
+		{"don't STOP-me now", "Title", "Don't Stop-Me Now"},
+		{"myHTTPFile v2", "snake_case", "my_http_file_v2"},
+		{"Holiday Photos 2024", "kebab-case", "holiday-photos-2024"},
+		{"holiday_photos-2024", "camelCase", "holidayPhotos2024"},
+		{"unchanged", "unknown", "unchanged"},
+	}
+	for _, test := range tests {
+		if got := ConvertCase(test.text, test.mode, smallWords); got != test.want {
+			t.Errorf("%q as %s: got %q, want %q", test.text, test.mode, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// Exit codes of the command-line mode
+const (
+	exitOK        = 0 // Files renamed, or dry run without conflicts
+	exitError     = 1 // Loading or renaming failed
+	exitUsage     = 2 // Invalid arguments or rules
+	exitConflicts = 3 // Conflicting names block the rename
+)
+
+// Case names accepted on the command line
+var cliCaseModes = map[string]string{
+	"lower": "lower", "upper": "UPPER", "title": "Title",
+	"snake": "snake_case", "kebab": "kebab-case", "camel": "camelCase",
+}
+
+// Settings of the command-line mode that are not part of the processor
+type cliOptions struct {
+	dryRun     bool
+	savePreset string
+	watch      bool
+	interval   time.Duration
+	mapping    string
+}
+
+// ruleFlag adds a step to the pipeline each time the flag is used, so steps keep the order of the arguments
+type ruleFlag struct {
+	rules *[]RenameRule
+	build func(value string) (RenameRule, error)
+	bool  bool
+}
+
+func (f *ruleFlag) String() string   { return "" }
+func (f *ruleFlag) IsBoolFlag() bool { return f.bool }
+
+func (f *ruleFlag) Set(value string) error {
+	rule, err := f.build(value)
+	if err != nil {
+		return err
+	}
+	*f.rules = append(*f.rules, rule)
+	return nil
+}
+
+// optionFlag changes a setting of the step added last
+type optionFlag struct {
+	rules *[]RenameRule
+	apply func(rule *RenameRule, value string) error
+	bool  bool
+}
+
+func (f *optionFlag) String() string   { return "" }
+func (f *optionFlag) IsBoolFlag() bool { return f.bool }
+
+func (f *optionFlag) Set(value string) error {
+	if len(*f.rules) == 0 {
+		return fmt.Errorf("must follow a step such as --number or --regex")
+	}
+	return f.apply(&(*f.rules)[len(*f.rules)-1], value)
+}
+
+// presetFlag loads a preset file, its steps are added where the flag is given.
+// The preset replaces the filter, order, subfolder and conflict settings, so they must be given after it.
+type presetFlag struct {
+	rp    *RenamerProcessor
+	flags *flag.FlagSet
+}
+
+// Flags that are not saved in presets, they may be given before --preset
+var cliRunFlags = []string{"dir", "dry-run", "preset", "save-preset", "mapping", "watch", "interval"}
+
+func (f *presetFlag) String() string { return "" }
+
+func (f *presetFlag) Set(path string) error {
+	// A setting given before the preset would be replaced without notice
+	var settings []string
+	f.flags.Visit(func(given *flag.Flag) {
+		switch given.Value.(type) {
+		case *ruleFlag, *optionFlag:
+			return
+		}
+		if !slices.Contains(cliRunFlags, given.Name) {
+			settings = append(settings, "--"+given.Name)
+		}
+	})
+	if len(settings) > 0 {
+		return fmt.Errorf("%s must be given after --preset, the preset replaces it", strings.Join(settings, ", "))
+	}
+	preset, err := LoadPresetFile(path)
+	if err != nil {
+		return err
+	}
+	rules := f.rp.Rules
+	f.rp.ApplyPreset(preset)
+	f.rp.Rules = append(rules, f.rp.Rules...)
+	return nil
+}
+
+// Parse the mode of an attribute filter
+func attributeFlag(mode *string) func(string) error {
+	return func(value string) error {
+		value = capitalize(strings.ToLower(value))
+		if !slices.Contains(AttributeFilters, value) {
+			return fmt.Errorf("expected include, exclude or only")
+		}
+		*mode = value
+		return nil
+	}
+}
+
+// Split "find=replacement" at the first "="
+func splitReplacement(value string) (string, string, error) {
+	find, replacement, found := strings.Cut(value, "=")
+	if !found {
+		return "", "", fmt.Errorf("expected FIND=REPLACEMENT")
+	}
+	return find, replacement, nil
+}
+
+// Parse an integer option of the last step
+func intOption(set func(rule *RenameRule, number int)) func(*RenameRule, string) error {
+	return func(rule *RenameRule, value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		set(rule, number)
+		return nil
+	}
+}
+
+// Create the flag set of the command-line mode, rules are appended to the processor in argument order
+func newCLIFlags(rp *RenamerProcessor, options *cliOptions, output io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet("batch-renamer", flag.ContinueOnError)
+	flags.SetOutput(output)
+	rules := &rp.Rules
+	// Builders for steps with a single text value
+	textRule := func(ruleType, mode string) func(string) (RenameRule, error) {
+		return func(value string) (RenameRule, error) {
+			rule := NewRenameRule(ruleType)
+			rule.Mode = mode
+			rule.Value = value
+			return rule, nil
+		}
+	}
+	replaceRule := func(ruleType string) func(string) (RenameRule, error) {
+		return func(value string) (RenameRule, error) {
+			rule := NewRenameRule(ruleType)
+			find, replacement, err := splitReplacement(value)
+			rule.Pattern, rule.Replacement = find, replacement
+			return rule, err
+		}
+	}
+
+	flags.StringVar(&rp.FolderPath, "dir", "", "folder with the files to rename (required)")
+	flags.StringVar(&rp.FilterExt, "filter", "", "extensions to include, e.g. .jpg;.png")
+	flags.StringVar(&rp.NameFilter, "name", "", "names to include, e.g. \"IMG_2024*.jpg;not *_thumb.*\"")
+	flags.StringVar(&rp.RegexFilter, "match", "", "regular expression the full name must match")
+	flags.Func("min-size", "smallest file size, e.g. 500, 10KB or 1.5MB", func(value string) (err error) {
+		rp.MinSize, err = ParseSize(value)
+		return err
+	})
+	flags.Func("max-size", "largest file size, e.g. 20MB", func(value string) (err error) {
+		rp.MaxSize, err = ParseSize(value)
+		return err
+	})
+	flags.Func("after", "modified on or after, e.g. 2024-05-01 or \"2024-05-01 08:00\"", func(value string) (err error) {
+		rp.ModifiedAfter, err = ParseDate(value, false)
+		return err
+	})
+	flags.Func("before", "modified on or before this day, or before this time, e.g. 2024-05-31", func(value string) (err error) {
+		rp.ModifiedBefore, err = ParseDate(value, true)
+		return err
+	})
+	flags.Func("hidden", "include, exclude or only hidden files and dotfiles (default include)", attributeFlag(&rp.HiddenFilter))
+	flags.Func("read-only", "include, exclude or only read-only files (default include)", attributeFlag(&rp.ReadOnlyFilter))
+	flags.Func("sort", "order of the files and their numbers: name, natural, modified, size or extension (default name)", func(value string) error {
+		mode := capitalize(strings.ToLower(value))
+		if !slices.Contains(SortModes, mode) {
+			return fmt.Errorf("expected name, natural, modified, size or extension")
+		}
+		rp.SortMode = mode
+		return nil
+	})
+	flags.BoolVar(&rp.SortDescending, "desc", false, "sort in descending order")
+	flags.Func("target", "rename files, folders or both (default files)", func(value string) error {
+		target := capitalize(strings.ToLower(value))
+		if !slices.Contains(Targets, target) {
+			return fmt.Errorf("expected files, folders or both")
+		}
+		rp.Target = target
+		return nil
+	})
+	flags.BoolVar(&rp.Recursive, "recursive", false, "also rename the files of subfolders")
+	flags.IntVar(&rp.MaxDepth, "max-depth", 0, "deepest subfolder level of --recursive, 0 for no limit")
+	flags.StringVar(&rp.IncludeFolders, "include-folders", "", "only enter subfolders matching these patterns, e.g. 2024*;raw")
+	flags.StringVar(&rp.ExcludeFolders, "exclude-folders", "", "never enter subfolders matching these patterns, e.g. .git;thumbs")
+	flags.BoolVar(&options.dryRun, "dry-run", false, "print the new names without renaming")
+	flags.Var(&presetFlag{rp: rp, flags: flags}, "preset", "load the filter, steps and conflict settings of a preset file")
+	flags.StringVar(&options.savePreset, "save-preset", "", "save the filter, steps and conflict settings to a preset file")
+	flags.StringVar(&options.mapping, "mapping", "", "CSV or TSV file of old and new names, files missing from it keep their name")
+	flags.BoolVar(&options.watch, "watch", false, "keep running and rename every new file once its size stops changing")
+	flags.DurationVar(&options.interval, "interval", defaultWatchInterval, "time between two scans of --watch")
+	flags.Func("on-conflict", "conflicting names: abort, skip or number (default abort)", func(value string) error {
+		policy := capitalize(strings.ToLower(value))
+		if !slices.Contains(ConflictPolicies, policy) {
+			return fmt.Errorf("expected abort, skip or number")
+		}
+		rp.ConflictPolicy = policy
+		return nil
+	})
+	flags.StringVar(&rp.NumberPattern, "number-pattern", rp.NumberPattern, "pattern used by --on-conflict number, must contain {n}")
+	flags.Func("on-error", "failed rename: stop, rollback or continue (default stop)", func(value string) error {
+		mode := capitalize(strings.ToLower(value))
+		if !slices.Contains(FailureModes, mode) {
+			return fmt.Errorf("expected stop, rollback or continue")
+		}
+		rp.FailureMode = mode
+		return nil
+	})
+
+	// Steps, applied in the order they are given
+	flags.Var(&ruleFlag{rules: rules, build: textRule("Prefix", "Add")}, "prefix-add", "step: add a prefix")
+	flags.Var(&ruleFlag{rules: rules, build: textRule("Prefix", "Remove")}, "prefix-remove", "step: remove a prefix")
+	flags.Var(&ruleFlag{rules: rules, build: textRule("Suffix", "Add")}, "suffix-add", "step: add a suffix before the extension")
+	flags.Var(&ruleFlag{rules: rules, build: textRule("Suffix", "Remove")}, "suffix-remove", "step: remove a suffix before the extension")
+	flags.Var(&ruleFlag{rules: rules, build: replaceRule("Replace")}, "replace", "step: replace text, FIND=REPLACEMENT")
+	flags.Var(&ruleFlag{rules: rules, build: replaceRule("Regex")}, "regex", "step: replace a regular expression, PATTERN=REPLACEMENT")
+	flags.Var(&ruleFlag{rules: rules, build: func(value string) (RenameRule, error) {
+		rule := NewRenameRule("Number")
+		rule.Mode = capitalize(strings.ToLower(value))
+		if rule.Mode != "Prefix" && rule.Mode != "Suffix" && rule.Mode != "Replace" {
+			return rule, fmt.Errorf("expected prefix, suffix or replace")
+		}
+		return rule, nil
+	}}, "number", "step: add a counter as prefix, suffix or replace the name")
+	flags.Var(&ruleFlag{rules: rules, build: textRule("Template", "")}, "template", "step: build the name from a template, e.g. {parent}_{n:04}{ext}")
+	flags.Var(&ruleFlag{rules: rules, build: func(value string) (RenameRule, error) {
+		rule := NewRenameRule("Case")
+		mode, ok := cliCaseModes[strings.ToLower(value)]
+		if !ok {
+			return rule, fmt.Errorf("expected lower, upper, title, snake, kebab or camel")
+		}
+		rule.Mode = mode
+		return rule, nil
+	}}, "case", "step: convert the case, lower, upper, title, snake, kebab or camel")
+	flags.Var(&ruleFlag{rules: rules, build: textRule("Extension", "Change")}, "ext-change", "step: change the extension")
+	flags.Var(&ruleFlag{rules: rules, build: func(string) (RenameRule, error) {
+		rule := NewRenameRule("Extension")
+		rule.Mode = "Remove"
+		return rule, nil
+	}, bool: true}, "ext-remove", "step: remove the extension")
+
+	// Options of the step given just before
+	flags.Var(&optionFlag{rules: rules, apply: intOption(func(rule *RenameRule, n int) { rule.Start = n })}, "start", "option: first number of --number or {n}")
+	flags.Var(&optionFlag{rules: rules, apply: intOption(func(rule *RenameRule, n int) { rule.Step = n })}, "step", "option: increment of --number or {n}")
+	flags.Var(&optionFlag{rules: rules, apply: intOption(func(rule *RenameRule, n int) { rule.Padding = n })}, "digits", "option: zero-padded digits of --number")
+	flags.Var(&optionFlag{rules: rules, apply: func(rule *RenameRule, value string) error {
+		rule.Separator = value
+		return nil
+	}}, "sep", "option: separator of --number")
+	flags.Var(&optionFlag{rules: rules, apply: func(rule *RenameRule, value string) error {
+		ignoreCase, err := strconv.ParseBool(value)
+		rule.IgnoreCase = ignoreCase
+		return err
+	}, bool: true}, "ignore-case", "option: --replace or --regex ignores case")
+	flags.Var(&optionFlag{rules: rules, apply: func(rule *RenameRule, value string) error {
+		scopes := map[string]string{"base": "Base", "ext": "Extension", "full": "Full"}
+		scope, ok := scopes[strings.ToLower(value)]
+		if !ok {
+			return fmt.Errorf("expected base, ext or full")
+		}
+		rule.Scope = scope
+		return nil
+	}}, "scope", "option: part of the name edited by --replace, --regex or --case, base, ext or full")
+	flags.Var(&optionFlag{rules: rules, apply: func(rule *RenameRule, value string) error {
+		rule.Value = value
+		return nil
+	}}, "small-words", "option: words kept lower case by --case title, comma separated")
+
+	flags.Usage = func() {
+		fmt.Fprintf(output, "Usage: batch-renamer --dir FOLDER [--preset FILE] [options] [steps]\n\n")
+		fmt.Fprintf(output, "Steps are applied in the order given, options apply to the step before them.\n")
+		fmt.Fprintf(output, "A preset replaces the filter, order, subfolder and conflict settings, give them after --preset.\n")
+		fmt.Fprintf(output, "Exit codes: 0 success, 1 error, 2 invalid arguments, 3 conflicting names.\n\n")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// Run the renamer without a window, returns the exit code
+func RunCLI(args []string, stdout, stderr io.Writer) int {
+	rp := NewRenamerProcessor()
+	var options cliOptions
+	flags := newCLIFlags(rp, &options, stderr)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected argument %q\n", flags.Arg(0))
+		return exitUsage
+	}
+	if rp.MaxDepth < 0 {
+		fmt.Fprintln(stderr, "--max-depth must not be negative")
+		return exitUsage
+	}
+	if err := rp.ValidateFilters(); err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return exitUsage
+	}
+	// Saving a preset does not need a folder
+	if options.savePreset != "" {
+		if err := SavePresetFile(options.savePreset, rp.Preset(PresetName(options.savePreset))); err != nil {
+			fmt.Fprintln(stderr, "Error saving preset:", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "Saved preset to %s\n", options.savePreset)
+		if rp.FolderPath == "" {
+			return exitOK
+		}
+	}
+	if rp.FolderPath == "" {
+		fmt.Fprintln(stderr, "--dir is required")
+		flags.Usage()
+		return exitUsage
+	}
+	// The journal is shared with the window, it needs the full path of the folder
+	if folder, err := filepath.Abs(rp.FolderPath); err == nil {
+		rp.FolderPath = folder
+	}
+	if options.watch {
+		if options.dryRun || options.interval <= 0 || options.mapping != "" {
+			fmt.Fprintln(stderr, "--watch needs a positive --interval and can't be combined with --dry-run or --mapping")
+			return exitUsage
+		}
+		return runWatch(rp, options.interval, stdout, stderr)
+	}
+	// Load the folder and generate the new names like the Preview button
+	if err := rp.LoadFiles(rp.FolderPath); err != nil {
+		fmt.Fprintln(stderr, "Error loading files:", err)
+		return exitError
+	}
+	if options.mapping != "" {
+		if err := importMapping(rp, options.mapping, stderr); err != nil {
+			fmt.Fprintln(stderr, "Error:", err)
+			return exitError
+		}
+	}
+	if err := rp.GenerateNewNames(); err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return exitUsage
+	}
+	printPreview(stdout, rp)
+	if conflicts := rp.ConflictCount(); conflicts > 0 {
+		fmt.Fprintf(stderr, "%d conflicting names, nothing renamed\n", conflicts)
+		return exitConflicts
+	}
+	if options.dryRun {
+		return exitOK
+	}
+	successCount, err := rp.RenameFiles()
+	printResults(stdout, rp.Results)
+	// Record the renames in the journal of the window, its Undo button reverts them
+	if journalErr := NewJournal(appStorageFolder()).Append(rp.FolderPath, rp.LastRenames); journalErr != nil {
+		fmt.Fprintln(stderr, "Warning: the renames could not be recorded for undo:", journalErr)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "Successfully renamed %d files\n", successCount)
+	return exitOK
+}
+
+// Rename new files of the folder until the process is interrupted
+func runWatch(rp *RenamerProcessor, interval time.Duration, stdout, stderr io.Writer) int {
+	logger := log.New(stdout, "", log.LstdFlags)
+	watcher := NewWatcher(rp.FolderPath, rp.Preset(""), logger.Printf)
+	watcher.Interval = interval
+	watcher.Journal = NewJournal(appStorageFolder())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := watcher.Run(ctx); err != nil {
+		fmt.Fprintln(stderr, "Error:", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// Use the new names of a mapping file and print the rows that are not used
+func importMapping(rp *RenamerProcessor, path string, stderr io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	report, err := rp.ImportMapping(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, problem := range report.Problems(math.MaxInt) {
+		fmt.Fprintln(stderr, problem)
+	}
+	return nil
+}
+
+// Print the old and new name of every filtered file
+func printPreview(output io.Writer, rp *RenamerProcessor) {
+	writer := tabwriter.NewWriter(output, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "OLD NAME\tNEW NAME\tSTATUS")
+	for i, file := range rp.FilteredFiles {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", file.Path(), file.NewPath(rp.NewNames[i]), rp.Statuses[i])
+	}
+	writer.Flush()
+}
+
+// Print the outcome of the files that were not renamed
+func printResults(output io.Writer, results []RenameResult) {
+	for _, result := range results {
+		if result.Outcome != "Renamed" {
+			fmt.Fprintf(output, "%s: %s (%s)\n", result.Outcome, result.Old, result.Reason)
+		}
+	}
+}
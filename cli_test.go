@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCLIModesIgnoreCase(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "a.txt", "b.txt")
+	var stdout, stderr bytes.Buffer
+	code := RunCLI([]string{"--dir", folder, "--replace", "a=b", "--on-conflict", "skip", "--on-error", "ROLLBACK", "--dry-run"}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if !bytes.Contains(stdout.Bytes(), []byte("Skipped")) {
+		t.Errorf("the Skip policy was not used:\n%s", stdout.String())
+	}
+	if code := RunCLI([]string{"--dir", folder, "--on-conflict", "later"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("unknown policy: exit code %d, want %d", code, exitUsage)
+	}
+}
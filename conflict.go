@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// RenameStatus describes what will happen to a file when the batch is renamed
+type RenameStatus string
+
+const (
+	StatusUnchanged RenameStatus = "Unchanged" // New name is the same as the old name
+	StatusOK        RenameStatus = "OK"        // File will be renamed
+	StatusDuplicate RenameStatus = "Duplicate" // Another file in the batch gets the same name
+	StatusExists    RenameStatus = "Exists"    // A file with the new name already exists on disk
+	StatusInvalid   RenameStatus = "Invalid"   // New name is not a valid file name
+	StatusSkipped   RenameStatus = "Skipped"   // Conflicting file is left untouched by the Skip policy
+	StatusResolved  RenameStatus = "Numbered"  // Conflicting name was made unique by the Number policy
+	StatusExcluded  RenameStatus = "Excluded"  // File was unchecked and is left untouched
+)
+
+// Policies for files whose new name conflicts with another file
+var ConflictPolicies = []string{"Abort", "Skip", "Number"}
+
+// Suggested patterns for the Number policy, {n} is replaced by the counter
+var NumberPatterns = []string{" ({n})", "_{n}", "-{n}"}
+
+// Check whether the status blocks the rename
+func (s RenameStatus) IsConflict() bool {
+	return s == StatusDuplicate || s == StatusExists || s == StatusInvalid
+}
+
+// Names reserved by Windows, with or without an extension
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// Check whether the name can be used as a file name on this platform
+func IsValidFileName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	if strings.ContainsAny(name, "/\x00") {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		if strings.ContainsAny(name, `\<>:"|?*`) || strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+			return false
+		}
+		for _, r := range name {
+			if r < 32 {
+				return false
+			}
+		}
+		base := strings.ToUpper(strings.TrimSuffix(name, filepath.Ext(name)))
+		for _, reserved := range windowsReservedNames {
+			if base == reserved {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Get the key used to compare names, Windows and macOS file systems ignore case by default
+func nameKey(name string) string {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// Compute the status of every file, a new name may reuse the old name of a file that is renamed in the same batch
+func (rp *RenamerProcessor) checkConflicts() {
+	// Every path is looked up on disk once, however many files want it
+	rp.stats = make(map[string]statResult)
+	defer func() { rp.stats = nil }()
+	generated := slices.Clone(rp.NewNames)
+	// Start by assuming every changed file leaves its old name
+	rp.moving = make(map[string]bool, len(generated))
+	for i, file := range rp.FilteredFiles {
+		if generated[i] != file.Name() {
+			rp.moving[nameKey(file.Path())] = true
+		}
+	}
+	claims := rp.computeStatuses()
+	// A file that stays blocks its old name for the file renamed to it, which may then stay as well.
+	// Follow these chains once, starting from the changed files that stay.
+	waiting := make(map[string][]int, len(generated)) // Moving files by their target path
+	var staying []int
+	for i, file := range rp.FilteredFiles {
+		switch {
+		case rp.Statuses[i].moves():
+			target := nameKey(file.NewPath(rp.NewNames[i]))
+			waiting[target] = append(waiting[target], i)
+		case rp.moving[nameKey(file.Path())]:
+			staying = append(staying, i)
+		}
+	}
+	for len(staying) > 0 {
+		j := staying[len(staying)-1]
+		staying = staying[:len(staying)-1]
+		oldPath := nameKey(rp.FilteredFiles[j].Path())
+		delete(rp.moving, oldPath)
+		blocked := waiting[oldPath]
+		delete(waiting, oldPath)
+		for _, i := range blocked {
+			rp.blockFile(i, generated[i], claims)
+			if !rp.Statuses[i].moves() {
+				staying = append(staying, i)
+				continue
+			}
+			target := nameKey(rp.FilteredFiles[i].NewPath(rp.NewNames[i]))
+			waiting[target] = append(waiting[target], i)
+		}
+	}
+}
+
+// Check whether the file leaves its old name in this batch
+func (s RenameStatus) moves() bool {
+	return s == StatusOK || s == StatusResolved
+}
+
+// Names claimed by the Skip and Number policies
+type nameClaims struct {
+	paths map[string]bool // Paths a file of the batch is renamed to or stays at
+	next  map[string]int  // Next counter to try for each name renumbered by the Number policy
+}
+
+// Compute the status of every file from its old and new name, then apply the conflict policy.
+// Returns the names claimed by the policy, nil for the Abort policy.
+func (rp *RenamerProcessor) computeStatuses() *nameClaims {
+	rp.Statuses = make([]RenameStatus, len(rp.NewNames))
+	// Count how many files want each target path
+	targets := make(map[string]int, len(rp.NewNames))
+	for i, file := range rp.FilteredFiles {
+		targets[nameKey(file.NewPath(rp.NewNames[i]))]++
+	}
+	for i, file := range rp.FilteredFiles {
+		newName := rp.NewNames[i]
+		switch {
+		case rp.Excluded[file.Path()]:
+			rp.Statuses[i] = StatusExcluded
+		case newName == file.Name():
+			rp.Statuses[i] = StatusUnchanged
+		case !IsValidFileName(newName):
+			rp.Statuses[i] = StatusInvalid
+		case targets[nameKey(file.NewPath(newName))] > 1:
+			rp.Statuses[i] = StatusDuplicate
+		case rp.targetExists(file, newName):
+			rp.Statuses[i] = StatusExists
+		default:
+			rp.Statuses[i] = StatusOK
+		}
+	}
+	if rp.ConflictPolicy == "Skip" || rp.ConflictPolicy == "Number" {
+		return rp.resolveConflicts()
+	}
+	return nil
+}
+
+// Skip or renumber conflicting files, the first file claiming a free name keeps it
+func (rp *RenamerProcessor) resolveConflicts() *nameClaims {
+	// Paths of files that stay as they are can't be claimed
+	claims := &nameClaims{paths: make(map[string]bool, len(rp.NewNames)), next: make(map[string]int)}
+	for i, status := range rp.Statuses {
+		if status == StatusUnchanged || status == StatusExcluded {
+			claims.paths[nameKey(rp.FilteredFiles[i].NewPath(rp.NewNames[i]))] = true
+		}
+	}
+	for i, file := range rp.FilteredFiles {
+		status := rp.Statuses[i]
+		if status == StatusUnchanged || status == StatusExcluded {
+			continue
+		}
+		// Invalid names can only be skipped
+		if status == StatusInvalid {
+			if rp.ConflictPolicy == "Skip" {
+				rp.Statuses[i] = StatusSkipped
+			}
+			continue
+		}
+		newName := rp.NewNames[i]
+		if rp.isFree(file, newName, claims) {
+			claims.paths[nameKey(file.NewPath(newName))] = true
+			rp.Statuses[i] = StatusOK
+			continue
+		}
+		rp.blockFile(i, newName, claims)
+	}
+	return claims
+}
+
+// Apply the conflict policy to a file whose new name is taken, newName is the name generated by the rules
+func (rp *RenamerProcessor) blockFile(i int, newName string, claims *nameClaims) {
+	switch rp.ConflictPolicy {
+	case "Skip":
+		rp.Statuses[i] = StatusSkipped
+	case "Number":
+		rp.numberFile(i, newName, claims)
+	default:
+		rp.Statuses[i] = StatusExists
+	}
+}
+
+// Count up until the numbered name is free. Names only get taken while the batch is checked, so the
+// counter of a name continues after the last number given for it instead of starting at 1 again.
+func (rp *RenamerProcessor) numberFile(i int, newName string, claims *nameClaims) {
+	file := rp.FilteredFiles[i]
+	key := nameKey(file.NewPath(newName))
+	for n := max(claims.next[key], 1); ; n++ {
+		candidate := numberedName(newName, rp.NumberPattern, n)
+		// Never rename to a name the platform refuses
+		if !IsValidFileName(candidate) {
+			rp.Statuses[i] = StatusInvalid
+			return
+		}
+		if candidate == file.Name() || rp.isFree(file, candidate, claims) {
+			claims.next[key] = n + 1
+			claims.paths[nameKey(file.NewPath(candidate))] = true
+			rp.NewNames[i] = candidate
+			rp.Statuses[i] = StatusResolved
+			if candidate == file.Name() {
+				rp.Statuses[i] = StatusUnchanged
+			}
+			return
+		}
+	}
+}
+
+// Check whether the file can be renamed to the name: no file of the batch claimed it and no other file uses it
+func (rp *RenamerProcessor) isFree(file FileEntry, name string, claims *nameClaims) bool {
+	return !claims.paths[nameKey(file.NewPath(name))] && !rp.targetExists(file, name)
+}
+
+// Check that the Number policy can build valid names with the pattern, it must not move files to another folder
+func ValidateNumberPattern(pattern string) error {
+	if !strings.Contains(pattern, "{n}") {
+		return fmt.Errorf("conflict number pattern must contain {n}")
+	}
+	if strings.ContainsAny(pattern, `/\`) || !IsValidFileName(numberedName("a.txt", pattern, 1)) {
+		return fmt.Errorf("conflict number pattern %q must not contain path separators or characters invalid in file names", pattern)
+	}
+	return nil
+}
+
+// Insert the numbered pattern between base name and extension, e.g. "a.txt" → "a (2).txt"
+func numberedName(name, pattern string, n int) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + strings.ReplaceAll(pattern, "{n}", fmt.Sprint(n)) + ext
+}
+
+// Check whether another file in the same subfolder already uses the new name, a case-only rename of the same file is allowed
+func (rp *RenamerProcessor) targetExists(file FileEntry, newName string) bool {
+	existing, err := rp.lstat(filepath.Join(rp.FolderPath, file.NewPath(newName)))
+	if err != nil || os.SameFile(existing, file.FileInfo) {
+		return false
+	}
+	// The name is free once the file using it is renamed in the same batch
+	return !rp.moving[nameKey(file.NewPath(newName))]
+}
+
+// Count the files whose new name blocks the rename
+func (rp *RenamerProcessor) ConflictCount() int {
+	count := 0
+	for _, status := range rp.Statuses {
+		if status.IsConflict() {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func TestCheckConflicts(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        []string
+		policy       string
+		filter       string            // Extensions of the batch, the other files only take names on disk
+		renames      map[string]string // New names typed by hand
+		excluded     []string
+		wantNames    []string
+		wantStatuses []RenameStatus
+	}{
+		{
+			name:         "swap",
+			files:        []string{"a.txt", "b.txt"},
+			renames:      map[string]string{"a.txt": "b.txt", "b.txt": "a.txt"},
+			wantNames:    []string{"b.txt", "a.txt"},
+			wantStatuses: []RenameStatus{StatusOK, StatusOK},
+		},
+		{
+			name:         "shift",
+			files:        []string{"1.txt", "2.txt", "3.txt"},
+			renames:      map[string]string{"1.txt": "2.txt", "2.txt": "3.txt", "3.txt": "4.txt"},
+			wantNames:    []string{"2.txt", "3.txt", "4.txt"},
+			wantStatuses: []RenameStatus{StatusOK, StatusOK, StatusOK},
+		},
+		{
+			name:         "duplicate",
+			files:        []string{"a.txt", "b.txt"},
+			renames:      map[string]string{"a.txt": "x.txt", "b.txt": "x.txt"},
+			wantNames:    []string{"x.txt", "x.txt"},
+			wantStatuses: []RenameStatus{StatusDuplicate, StatusDuplicate},
+		},
+		{
+			name:         "unchanged file",
+			files:        []string{"a.txt", "c.txt"},
+			renames:      map[string]string{"a.txt": "c.txt"},
+			wantNames:    []string{"c.txt", "c.txt"},
+			wantStatuses: []RenameStatus{StatusDuplicate, StatusUnchanged},
+		},
+		{
+			name:         "file outside the batch",
+			files:        []string{"a.txt", "c.md"},
+			filter:       ".txt",
+			renames:      map[string]string{"a.txt": "c.md"},
+			wantNames:    []string{"c.md"},
+			wantStatuses: []RenameStatus{StatusExists},
+		},
+		{
+			name:         "invalid name",
+			files:        []string{"a.txt"},
+			renames:      map[string]string{"a.txt": "a/b.txt"},
+			wantNames:    []string{"a/b.txt"},
+			wantStatuses: []RenameStatus{StatusInvalid},
+		},
+		{
+			name:         "shift blocked by an excluded file",
+			files:        []string{"1.txt", "2.txt", "3.txt"},
+			renames:      map[string]string{"1.txt": "2.txt", "2.txt": "3.txt"},
+			excluded:     []string{"3.txt"},
+			wantNames:    []string{"2.txt", "3.txt", "3.txt"},
+			wantStatuses: []RenameStatus{StatusExists, StatusDuplicate, StatusExcluded},
+		},
+		{
+			name:         "skip a blocked shift",
+			files:        []string{"1.txt", "2.txt", "3.txt", "a.txt"},
+			policy:       "Skip",
+			renames:      map[string]string{"1.txt": "2.txt", "2.txt": "3.txt", "a.txt": "b.txt"},
+			excluded:     []string{"3.txt"},
+			wantNames:    []string{"2.txt", "3.txt", "3.txt", "b.txt"},
+			wantStatuses: []RenameStatus{StatusSkipped, StatusSkipped, StatusExcluded, StatusOK},
+		},
+		{
+			name:         "skip duplicates after the first",
+			files:        []string{"a.txt", "b.txt"},
+			policy:       "Skip",
+			renames:      map[string]string{"a.txt": "x.txt", "b.txt": "x.txt"},
+			wantNames:    []string{"x.txt", "x.txt"},
+			wantStatuses: []RenameStatus{StatusOK, StatusSkipped},
+		},
+		{
+			name:         "skip invalid names",
+			files:        []string{"a.txt"},
+			policy:       "Skip",
+			renames:      map[string]string{"a.txt": "a/b.txt"},
+			wantNames:    []string{"a/b.txt"},
+			wantStatuses: []RenameStatus{StatusSkipped},
+		},
+		{
+			name:         "number duplicates around a taken number",
+			files:        []string{"a.txt", "b.txt", "c.txt", "x (2).txt"},
+			policy:       "Number",
+			renames:      map[string]string{"a.txt": "x.txt", "b.txt": "x.txt", "c.txt": "x.txt"},
+			wantNames:    []string{"x.txt", "x (1).txt", "x (3).txt", "x (2).txt"},
+			wantStatuses: []RenameStatus{StatusOK, StatusResolved, StatusResolved, StatusUnchanged},
+		},
+		{
+			name:         "number a shift blocked by an excluded file",
+			files:        []string{"1.txt", "2.txt", "3.txt"},
+			policy:       "Number",
+			renames:      map[string]string{"1.txt": "2.txt", "2.txt": "3.txt"},
+			excluded:     []string{"3.txt"},
+			wantNames:    []string{"2.txt", "3 (1).txt", "3.txt"},
+			wantStatuses: []RenameStatus{StatusOK, StatusResolved, StatusExcluded},
+		},
+		{
+			name:         "number back to the old name",
+			files:        []string{"x (1).txt", "x.txt"},
+			policy:       "Number",
+			renames:      map[string]string{"x (1).txt": "x.txt"},
+			wantNames:    []string{"x (1).txt", "x.txt"},
+			wantStatuses: []RenameStatus{StatusUnchanged, StatusUnchanged},
+		},
+		{
+			name:         "number keeps invalid names",
+			files:        []string{"a.txt"},
+			policy:       "Number",
+			renames:      map[string]string{"a.txt": "a/b.txt"},
+			wantNames:    []string{"a/b.txt"},
+			wantStatuses: []RenameStatus{StatusInvalid},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folder := t.TempDir()
+			createFiles(t, folder, test.files...)
+			rp := NewRenamerProcessor()
+			rp.ConflictPolicy = cmp.Or(test.policy, "Abort")
+			rp.FilterExt = test.filter
+			if err := rp.LoadFiles(folder); err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range rp.FilteredFiles {
+				if newName, ok := test.renames[file.Name()]; ok {
+					rp.SetOverride(file, newName)
+				}
+				if slices.Contains(test.excluded, file.Name()) {
+					rp.SetExcluded(file, true)
+				}
+			}
+			if err := rp.GenerateNewNames(); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(rp.NewNames, test.wantNames) || !slices.Equal(rp.Statuses, test.wantStatuses) {
+				t.Errorf("got %q %v, want %q %v", rp.NewNames, rp.Statuses, test.wantNames, test.wantStatuses)
+			}
+		})
+	}
+}
+
+func TestNumberingSkipsExcludedFiles(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "a.txt", "b.txt", "c.txt", "d.txt")
+	rp := NewRenamerProcessor()
+	rule := NewRenameRule("Template")
+	rule.Value = "{n}{ext}"
+	rp.AddRule(rule)
+	if err := rp.LoadFiles(folder); err != nil {
+		t.Fatal(err)
+	}
+	// An excluded file takes no number, a name typed by hand still uses its number
+	rp.SetExcluded(rp.FilteredFiles[1], true)
+	rp.SetOverride(rp.FilteredFiles[2], "keep.txt")
+	if err := rp.GenerateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1.txt", "b.txt", "keep.txt", "3.txt"}
+	if !slices.Equal(rp.NewNames, want) {
+		t.Errorf("got %q, want %q", rp.NewNames, want)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Check whether the name has one of the extensions, with or without the dot, ignoring case
+func matchesExtension(name string, exts []string) bool {
+	fileExt := strings.ToLower(filepath.Ext(name))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		// Ensure the extension starts with a dot
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if ext == fileExt {
+			return true
+		}
+	}
+	return false
+}
+
+// Check the name against semicolon separated glob patterns, ignoring case. Patterns starting with "not "
+// exclude names, the name must match at least one of the other patterns if there are any. An invalid pattern
+// matches no names.
+func matchesNameFilter(name, filter string) bool {
+	name = strings.ToLower(name)
+	hasInclude, included := false, false
+	for _, pattern := range strings.Split(filter, ";") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if exclude, ok := strings.CutPrefix(pattern, "not "); ok {
+			if matched, err := filepath.Match(strings.TrimSpace(exclude), name); matched || err != nil {
+				return false
+			}
+			continue
+		}
+		hasInclude = true
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false
+		}
+		if matched {
+			included = true
+		}
+	}
+	return !hasInclude || included
+}
+
+// Check the name and folder patterns and the regular expression, an invalid filter matches no files
+func (rp *RenamerProcessor) ValidateFilters() error {
+	if _, err := regexp.Compile(rp.RegexFilter); err != nil {
+		return fmt.Errorf("invalid filter regex: %w", err)
+	}
+	for _, patterns := range []string{rp.NameFilter, rp.IncludeFolders, rp.ExcludeFolders} {
+		for _, pattern := range strings.Split(patterns, ";") {
+			pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "not ")
+			if _, err := filepath.Match(strings.TrimSpace(pattern), ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Modes of the hidden and read-only filters
+var AttributeFilters = []string{"Include", "Exclude", "Only"}
+
+// Check whether any size, date or attribute criterion is set
+func (rp *RenamerProcessor) hasAdvancedFilter() bool {
+	return rp.MinSize > 0 || rp.MaxSize > 0 || !rp.ModifiedAfter.IsZero() || !rp.ModifiedBefore.IsZero() ||
+		(rp.HiddenFilter != "" && rp.HiddenFilter != "Include") || (rp.ReadOnlyFilter != "" && rp.ReadOnlyFilter != "Include")
+}
+
+// Check the size, modification time and attributes of the file, the size range only applies to files
+func (rp *RenamerProcessor) matchesAdvancedFilter(file FileEntry) bool {
+	if !file.IsDir() {
+		if file.Size() < rp.MinSize || (rp.MaxSize > 0 && file.Size() > rp.MaxSize) {
+			return false
+		}
+	}
+	modified := file.ModTime()
+	if modified.Before(rp.ModifiedAfter) || (!rp.ModifiedBefore.IsZero() && !modified.Before(rp.ModifiedBefore)) {
+		return false
+	}
+	return matchesAttribute(isHidden(file.FileInfo), rp.HiddenFilter) &&
+		matchesAttribute(file.Mode().Perm()&0o200 == 0, rp.ReadOnlyFilter)
+}
+
+// Check an attribute against "Include", "Exclude" or "Only"
+func matchesAttribute(set bool, mode string) bool {
+	switch mode {
+	case "Exclude":
+		return !set
+	case "Only":
+		return set
+	}
+	return true
+}
+
+// Units accepted by ParseSize
+var sizeUnits = map[string]float64{"": 1, "B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40}
+
+// Parse a size such as "500", "10KB" or "1.5 MB", empty means no limit
+func ParseSize(text string) (int64, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if text == "" {
+		return 0, nil
+	}
+	number := strings.TrimRight(text, "KMGTB ")
+	unit := strings.TrimSpace(text[len(number):])
+	factor, ok := sizeUnits[unit]
+	value, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q, e.g. 500, 10KB or 1.5MB", text)
+	}
+	return int64(value * factor), nil
+}
+
+// Format a size limit for an entry, the opposite of ParseSize
+func FormatSize(size int64) string {
+	if size == 0 {
+		return ""
+	}
+	for _, unit := range []string{"TB", "GB", "MB", "KB"} {
+		if size%int64(sizeUnits[unit]) == 0 {
+			return fmt.Sprintf("%d%s", size/int64(sizeUnits[unit]), unit)
+		}
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+// Layouts accepted by ParseDate
+var dateLayouts = []string{"2006-01-02 15:04", "2006-01-02"}
+
+// Parse a local date such as "2024-05-31" or "2024-05-31 18:00", empty means no limit. A date without
+// a time ends the range at the end of that day if end is set.
+func ParseDate(text string, end bool) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range dateLayouts {
+		date, err := time.ParseInLocation(layout, text, time.Local)
+		if err != nil {
+			continue
+		}
+		if end && layout == "2006-01-02" {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, e.g. 2024-05-31 or 2024-05-31 18:00", text)
+}
+
+// Format a date limit for an entry, the opposite of ParseDate
+func FormatDate(date time.Time, end bool) string {
+	if date.IsZero() {
+		return ""
+	}
+	date = date.Local()
+	if date.Hour() == 0 && date.Minute() == 0 {
+		if end {
+			date = date.AddDate(0, 0, -1)
+		}
+		return date.Format("2006-01-02")
+	}
+	return date.Format("2006-01-02 15:04")
+}
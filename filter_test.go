@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestMatchesNameFilter(t *testing.T) {
+	tests := []struct {
+		name, filter string
+		want         bool
+	}{
+		{"IMG_2024.jpg", "", true},
+		{"IMG_2024.jpg", "img_*.JPG", true},
+		{"notes.txt", "*.jpg;*.png", false},
+		{"photo.png", "*.jpg; *.png", true},
+		{"a_thumb.jpg", "*.jpg;not *_thumb.*", false},
+		{"a.jpg", "not *_thumb.*", true},
+		// An invalid pattern matches no names, also when it only excludes
+		{"a.jpg", "[a", false},
+		{"a.jpg", "*.jpg;not [thumb*", false},
+	}
+	for _, test := range tests {
+		if got := matchesNameFilter(test.name, test.filter); got != test.want {
+			t.Errorf("%q with %q: got %v, want %v", test.name, test.filter, got, test.want)
+		}
+	}
+}
+
+func TestInvalidFilterLeavesNoFiles(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "a.jpg", "b.jpg")
+	rp := NewRenamerProcessor()
+	if err := rp.LoadFiles(folder); err != nil {
+		t.Fatal(err)
+	}
+	for _, filter := range []string{"[a", "not [a"} {
+		rp.NameFilter = filter
+		rp.FilterFiles()
+		if rp.ValidateFilters() == nil || len(rp.FilteredFiles) != 0 {
+			t.Errorf("%q: kept %d files, want none and an error", filter, len(rp.FilteredFiles))
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Check whether the file is a dotfile, which file managers hide
+func isHidden(info os.FileInfo) bool {
+	return strings.HasPrefix(info.Name(), ".")
+}
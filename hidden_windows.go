@@ -0,0 +1,17 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Check whether the file has the hidden attribute or is a dotfile
+func isHidden(info os.FileInfo) bool {
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok && data.FileAttributes&syscall.FILE_ATTRIBUTE_HIDDEN != 0 {
+		return true
+	}
+	return strings.HasPrefix(info.Name(), ".")
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Number of batches kept in the journal
+const journalLimit = 100
+
+// Time to wait for another process using the journal, and age of a lock left behind by a process that crashed
+const (
+	journalLockWait  = 10 * time.Second
+	journalLockStale = 5 * time.Minute
+)
+
+// JournalEntry records one executed batch of renames
+type JournalEntry struct {
+	Folder  string     `json:"folder"`
+	Time    time.Time  `json:"time"`
+	Renames []RenameOp `json:"renames"`
+}
+
+// Journal stores executed batches in a JSON file so they can be undone later
+type Journal struct {
+	Path string
+
+	mu sync.Mutex // A watched folder records batches in the background, other processes use the lock file
+}
+
+// UndoReport describes the result of undoing a batch
+type UndoReport struct {
+	Restored int        // Files renamed back to their old name
+	Missing  []string   // Files no longer found under their new name
+	Blocked  []string   // Files whose old name is now used by another file
+	Left     []RenameOp // Renames of the batch that were not undone, they stay in the journal
+}
+
+// Create a journal stored in the given folder
+func NewJournal(folder string) *Journal {
+	return &Journal{Path: filepath.Join(folder, "rename-journal.json")}
+}
+
+// Get the storage folder of the app, where the window keeps its journal. The command line uses the same
+// folder so its renames can be undone from the window, it follows the app storage of Fyne on desktops.
+func appStorageFolder() string {
+	home, _ := os.UserHomeDir()
+	config, _ := os.UserConfigDir()
+	switch runtime.GOOS {
+	case "windows":
+		config = filepath.Join(home, "AppData", "Roaming")
+	case "darwin":
+		config = filepath.Join(home, "Library", "Preferences")
+	}
+	return filepath.Join(config, "fyne", appID)
+}
+
+// Load all recorded batches, oldest first
+func (j *Journal) Load() ([]JournalEntry, error) {
+	data, err := os.ReadFile(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []JournalEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("reading journal %s: %w", j.Path, err)
+	}
+	return entries, nil
+}
+
+// Save the batches, dropping the oldest ones above the limit
+func (j *Journal) save(entries []JournalEntry) error {
+	if len(entries) > journalLimit {
+		entries = entries[len(entries)-journalLimit:]
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0o755); err != nil {
+		return err
+	}
+	// Write a temporary file next to the journal and move it into place, a crash never leaves half a journal
+	file, err := os.CreateTemp(filepath.Dir(j.Path), filepath.Base(j.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), j.Path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// Lock the journal against this process and others, e.g. the command line run by a scheduler while the window is open
+func (j *Journal) lock() (unlock func(), err error) {
+	j.mu.Lock()
+	defer func() {
+		if err != nil {
+			j.mu.Unlock()
+		}
+	}()
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0o755); err != nil {
+		return nil, err
+	}
+	path := j.Path + ".lock"
+	for start := time.Now(); ; {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			file.Close()
+			return func() {
+				os.Remove(path)
+				j.mu.Unlock()
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > journalLockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Since(start) > journalLockWait {
+			return nil, fmt.Errorf("journal is in use by another process, remove %s if none is running", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Record an executed batch, empty batches are not recorded
+func (j *Journal) Append(folder string, renames []RenameOp) error {
+	if len(renames) == 0 {
+		return nil
+	}
+	unlock, err := j.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	entries, err := j.Load()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, JournalEntry{Folder: folder, Time: time.Now(), Renames: renames})
+	return j.save(entries)
+}
+
+// Get the most recent batch, nil if the journal is empty
+func (j *Journal) Last() (*JournalEntry, error) {
+	unlock, err := j.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	entries, err := j.Load()
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[len(entries)-1], nil
+}
+
+// Undo the most recent batch and remove it from the journal. The batch must be the one recorded at the given
+// time, the one the user confirmed, a batch recorded since by a watch or the command line is never undone instead.
+func (j *Journal) UndoLast(recorded time.Time) (*JournalEntry, UndoReport, error) {
+	unlock, err := j.lock()
+	if err != nil {
+		return nil, UndoReport{}, err
+	}
+	defer unlock()
+	entries, err := j.Load()
+	if err != nil {
+		return nil, UndoReport{}, err
+	}
+	if len(entries) == 0 {
+		return nil, UndoReport{}, fmt.Errorf("nothing to undo")
+	}
+	entry := entries[len(entries)-1]
+	if !entry.Time.Equal(recorded) {
+		return nil, UndoReport{}, fmt.Errorf("another batch was recorded at %s, check the last rename again", entry.Time.Format("15:04:05"))
+	}
+	report, undoErr := UndoEntry(entry)
+	// Only the restored files leave the journal, the others can be undone once they are back, e.g. a share is mounted again
+	entries = entries[:len(entries)-1]
+	if len(report.Left) > 0 {
+		entries = append(entries, JournalEntry{Folder: entry.Folder, Time: entry.Time, Renames: report.Left})
+	}
+	if err := j.save(entries); err != nil {
+		return &entry, report, err
+	}
+	return &entry, report, undoErr
+}
+
+// Rename the files of a batch back to their old names, skipping files that were moved or replaced since
+func UndoEntry(entry JournalEntry) (UndoReport, error) {
+	var report UndoReport
+	// Reverse every rename whose file still exists under the new name
+	var reverse []RenameOp
+	for _, op := range entry.Renames {
+		if _, err := os.Lstat(filepath.Join(entry.Folder, op.New)); err != nil {
+			report.Missing = append(report.Missing, op.New)
+			continue
+		}
+		reverse = append(reverse, RenameOp{Old: op.New, New: op.Old})
+	}
+	// An old name is blocked if a file that stays is using it, repeat until no more files stay
+	for {
+		leaving := make(map[string]bool, len(reverse))
+		for _, op := range reverse {
+			leaving[nameKey(op.Old)] = true
+		}
+		var ready []RenameOp
+		for _, op := range reverse {
+			_, err := os.Lstat(filepath.Join(entry.Folder, op.New))
+			if err == nil && !leaving[nameKey(op.New)] {
+				report.Blocked = append(report.Blocked, op.Old)
+				continue
+			}
+			ready = append(ready, op)
+		}
+		if len(ready) == len(reverse) {
+			break
+		}
+		reverse = ready
+	}
+	restored, err := executeRenames(entry.Folder, reverse, "Stop")
+	report.Restored = len(restored)
+	undone := make(map[string]bool, len(restored))
+	for _, op := range restored {
+		undone[op.Old] = true
+	}
+	for _, op := range entry.Renames {
+		if !undone[op.New] {
+			report.Left = append(report.Left, op)
+		}
+	}
+	if err != nil {
+		return report, fmt.Errorf("undo stopped after %d files: %w", report.Restored, err)
+	}
+	if len(report.Missing) > 0 || len(report.Blocked) > 0 {
+		return report, fmt.Errorf("partial undo: %d restored, %d missing, %d blocked", report.Restored, len(report.Missing), len(report.Blocked))
+	}
+	return report, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestUndoKeepsFilesNotRestored(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "x.txt", "y.txt")
+	journal := NewJournal(t.TempDir())
+	renames := []RenameOp{{"a.txt", "x.txt"}, {"b.txt", "y.txt"}}
+	if err := journal.Append(folder, renames); err != nil {
+		t.Fatal(err)
+	}
+	// y.txt is away, e.g. on a share that is not mounted
+	away := filepath.Join(t.TempDir(), "y.txt")
+	if err := os.Rename(filepath.Join(folder, "y.txt"), away); err != nil {
+		t.Fatal(err)
+	}
+	report, err := undoLast(t, journal)
+	if err == nil || report.Restored != 1 {
+		t.Fatalf("restored %d files (%v), want 1 and an error", report.Restored, err)
+	}
+	entry, err := journal.Last()
+	if err != nil || entry == nil || !slices.Equal(entry.Renames, renames[1:]) {
+		t.Fatalf("journal holds %v (%v), want the rename of b.txt", entry, err)
+	}
+	// Once the file is back, undoing again restores it and empties the journal
+	if err := os.Rename(away, filepath.Join(folder, "y.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := undoLast(t, journal); err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, folder, "a.txt", "b.txt")
+	if entry, err := journal.Last(); entry != nil || err != nil {
+		t.Errorf("journal still holds %v (%v)", entry, err)
+	}
+}
+
+func TestJournalSharedBetweenProcesses(t *testing.T) {
+	folder := t.TempDir()
+	// Each journal stands for another process, only the lock file keeps them apart
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			journal := NewJournal(folder)
+			for j := range 5 {
+				if err := journal.Append("folder", []RenameOp{{fmt.Sprint(i, j), "new"}}); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	entries, err := NewJournal(folder).Load()
+	if err != nil || len(entries) != 100 {
+		t.Errorf("journal holds %d batches (%v), want 100", len(entries), err)
+	}
+	checkEntries(t, folder, "rename-journal.json")
+}
+
+func TestUndoOnlyTheConfirmedBatch(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "x.txt", "y.txt")
+	journal := NewJournal(t.TempDir())
+	if err := journal.Append(folder, []RenameOp{{"a.txt", "x.txt"}}); err != nil {
+		t.Fatal(err)
+	}
+	confirmed, err := journal.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A watch records another batch while the confirmation is shown
+	if err := journal.Append(folder, []RenameOp{{"b.txt", "y.txt"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := journal.UndoLast(confirmed.Time); err == nil {
+		t.Error("undid a batch that was not confirmed")
+	}
+	checkEntries(t, folder, "x.txt", "y.txt")
+}
+
+// Undo the last batch like the Undo button, which confirms the batch first
+func undoLast(t *testing.T, journal *Journal) (UndoReport, error) {
+	t.Helper()
+	entry, err := journal.Last()
+	if err != nil || entry == nil {
+		t.Fatalf("nothing to undo (%v)", err)
+	}
+	_, report, err := journal.UndoLast(entry.Time)
+	return report, err
+}
@@ -1,15 +1,24 @@
 package main
 
 import (
+	"os"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 )
 
+// Unique ID of the app, it names the folder of the preferences and the journal
+const appID = "Batch Renamer"
+
 func main() {
+	// Run without a window when arguments are given, macOS may pass a -psn_ argument to the app bundle
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-psn_") {
+		os.Exit(RunCLI(os.Args[1:], os.Stdout, os.Stderr))
+	}
 	// Create the application
-	MyApp := app.NewWithID("Batch Renamer")
+	MyApp := app.NewWithID(appID)
 	// Load and Set the custom font file
 	//customFont := fyne.NewStaticResource("NotoSans", LoadFont("fonts/NotoSans-SemiBold.ttf"))
 	MyApp.Settings().SetTheme(&appTheme{regularFont: AppFont})
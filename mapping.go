@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// First cells of a header row, the row is skipped instead of being reported as a missing file
+var mappingHeaders = []string{"old", "old name", "old_name", "oldname", "from", "source", "original", "file", "filename", "name"}
+
+// MappingReport describes how the rows of an imported mapping were used
+type MappingReport struct {
+	Imported int      // Files that get the new name of their row
+	Missing  []string // Old names of rows without a file in the folder
+	Filtered []string // Files hidden by the filter, they are not renamed
+	Invalid  []string // Rows that could not be used, with their line number
+	Excluded int      // Filtered files without a row, they keep their name
+}
+
+// Import a CSV or TSV file with the old name in the first column and the new name in the second.
+// The mapping becomes the plan: it replaces the names typed by hand, and files without a row are excluded.
+func (rp *RenamerProcessor) ImportMapping(r io.Reader) (MappingReport, error) {
+	var report MappingReport
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return report, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff")) // Spreadsheets often save CSV files with a BOM
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = mappingDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	// Files are found by their path relative to the folder, e.g. "2024/IMG_01.jpg"
+	files := make(map[string]FileEntry, len(rp.Files))
+	for _, file := range rp.Files {
+		files[nameKey(file.Path())] = file
+	}
+	filtered := make(map[string]bool, len(rp.FilteredFiles))
+	for _, file := range rp.FilteredFiles {
+		filtered[nameKey(file.Path())] = true
+	}
+	overrides := make(map[string]string)
+	lines := make(map[string]int) // Line of the row that mapped each file
+	for first := true; ; first = false {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("reading mapping: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if len(row) < 2 {
+			report.Invalid = append(report.Invalid, fmt.Sprintf("line %d: expected an old and a new name", line))
+			continue
+		}
+		oldPath := filepath.Clean(filepath.FromSlash(strings.TrimSpace(row[0])))
+		newName := strings.TrimSpace(row[1])
+		file, ok := files[nameKey(oldPath)]
+		if !ok {
+			// A header row names the columns instead of a file
+			if first && slices.Contains(mappingHeaders, strings.ToLower(strings.TrimSpace(row[0]))) {
+				continue
+			}
+			report.Missing = append(report.Missing, oldPath)
+			continue
+		}
+		// The new name may repeat the subfolder of the file, it can't move the file to another one
+		if dir := filepath.Dir(filepath.FromSlash(newName)); dir != "." && filepath.Clean(dir) == file.Dir {
+			newName = filepath.Base(filepath.FromSlash(newName))
+		}
+		if !IsValidFileName(newName) {
+			report.Invalid = append(report.Invalid, fmt.Sprintf("line %d: %q is not a valid file name", line, newName))
+			continue
+		}
+		if previous, ok := lines[file.Path()]; ok {
+			report.Invalid = append(report.Invalid, fmt.Sprintf("line %d: %s is already renamed on line %d", line, file.Path(), previous))
+			continue
+		}
+		lines[file.Path()] = line
+		overrides[file.Path()] = newName
+		if !filtered[nameKey(file.Path())] {
+			report.Filtered = append(report.Filtered, file.Path())
+			continue
+		}
+		report.Imported++
+	}
+	rp.Overrides = overrides
+	rp.Excluded = nil
+	for _, file := range rp.FilteredFiles {
+		if _, ok := overrides[file.Path()]; !ok {
+			rp.SetExcluded(file, true)
+			report.Excluded++
+		}
+	}
+	return report, nil
+}
+
+// Guess the column delimiter from the first line: tab, semicolon or comma
+func mappingDelimiter(data []byte) rune {
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	switch {
+	case bytes.ContainsRune(first, '\t'):
+		return '\t'
+	case bytes.ContainsRune(first, ';') && !bytes.ContainsRune(first, ','):
+		return ';'
+	}
+	return ','
+}
+
+// Describe the rows of the mapping that are not renamed, at most limit of each kind
+func (r MappingReport) Problems(limit int) []string {
+	var problems []string
+	for _, kind := range []struct {
+		title string
+		names []string
+	}{
+		{"No such file", r.Missing},
+		{"Hidden by the filter", r.Filtered},
+		{"Invalid row", r.Invalid},
+	} {
+		for _, name := range limitNames(kind.names, limit) {
+			problems = append(problems, kind.title+": "+name)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMappingDelimiter(t *testing.T) {
+	tests := []struct {
+		data string
+		want rune
+	}{
+		{"old\tnew\na.txt\tb.txt\n", '\t'},
+		{"a.txt;b.txt\n", ';'},
+		{"a.txt,b.txt\n", ','},
+		{"a;1.txt,b.txt\n", ','}, // A semicolon in a name of a comma separated file
+		{"a.txt\n", ','},
+	}
+	for _, test := range tests {
+		if got := mappingDelimiter([]byte(test.data)); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.data, got, test.want)
+		}
+	}
+}
+
+func TestImportMapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		mapping   string
+		wantNames []string // New names of a.txt, b.txt and c.txt
+		wantError string   // Start of the first invalid row, if any
+		missing   int
+	}{
+		{
+			name:      "csv with header and BOM",
+			mapping:   "\ufeffOld Name,New Name\na.txt,x.txt\nb.txt,y.txt\n",
+			wantNames: []string{"x.txt", "y.txt", "c.txt"},
+		},
+		{
+			name:      "tsv swap",
+			mapping:   "a.txt\tb.txt\nb.txt\ta.txt\n",
+			wantNames: []string{"b.txt", "a.txt", "c.txt"},
+		},
+		{
+			name:      "semicolons",
+			mapping:   "a.txt;x.txt\n",
+			wantNames: []string{"x.txt", "b.txt", "c.txt"},
+		},
+		{
+			name:      "header only on the first line",
+			mapping:   "a.txt,x.txt\nname,y.txt\n",
+			wantNames: []string{"x.txt", "b.txt", "c.txt"},
+			missing:   1,
+		},
+		{
+			name:      "invalid and repeated rows",
+			mapping:   "a.txt,x.txt\na.txt,z.txt\nb.txt\nc.txt,\n",
+			wantNames: []string{"x.txt", "b.txt", "c.txt"},
+			wantError: "line 2:",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folder := t.TempDir()
+			createFiles(t, folder, "a.txt", "b.txt", "c.txt")
+			rp := NewRenamerProcessor()
+			if err := rp.LoadFiles(folder); err != nil {
+				t.Fatal(err)
+			}
+			report, err := rp.ImportMapping(strings.NewReader(test.mapping))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rp.GenerateNewNames(); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range test.wantNames {
+				if rp.NewNames[i] != want {
+					t.Errorf("%s: got %q, want %q", rp.FilteredFiles[i].Name(), rp.NewNames[i], want)
+				}
+			}
+			if len(report.Missing) != test.missing {
+				t.Errorf("got missing rows %q, want %d", report.Missing, test.missing)
+			}
+			if test.wantError == "" && len(report.Invalid) > 0 || test.wantError != "" && (len(report.Invalid) == 0 || !strings.HasPrefix(report.Invalid[0], test.wantError)) {
+				t.Errorf("got invalid rows %q, want %q first", report.Invalid, test.wantError)
+			}
+		})
+	}
+}
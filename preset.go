@@ -0,0 +1,196 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Version of the preset file format, files from newer versions are rejected
+const presetVersion = 1
+
+// Preset stores the settings of the renamer in a file that can be shared
+type Preset struct {
+	Version        int          `json:"version"`
+	Name           string       `json:"name,omitempty"`
+	FilterExt      string       `json:"filterExt,omitempty"`
+	NameFilter     string       `json:"nameFilter,omitempty"`
+	RegexFilter    string       `json:"regexFilter,omitempty"`
+	MinSize        int64        `json:"minSize,omitempty"`
+	MaxSize        int64        `json:"maxSize,omitempty"`
+	ModifiedAfter  time.Time    `json:"modifiedAfter,omitzero"`
+	ModifiedBefore time.Time    `json:"modifiedBefore,omitzero"`
+	HiddenFilter   string       `json:"hiddenFilter,omitempty"`
+	ReadOnlyFilter string       `json:"readOnlyFilter,omitempty"`
+	SortMode       string       `json:"sortMode,omitempty"`
+	SortDescending bool         `json:"sortDescending,omitempty"`
+	Target         string       `json:"target,omitempty"`
+	Recursive      bool         `json:"recursive,omitempty"`
+	MaxDepth       int          `json:"maxDepth,omitempty"`
+	IncludeFolders string       `json:"includeFolders,omitempty"`
+	ExcludeFolders string       `json:"excludeFolders,omitempty"`
+	Rules          []RenameRule `json:"rules"`
+	ConflictPolicy string       `json:"conflictPolicy,omitempty"`
+	NumberPattern  string       `json:"numberPattern,omitempty"`
+	FailureMode    string       `json:"failureMode,omitempty"`
+}
+
+// Get the current settings as a preset
+func (rp *RenamerProcessor) Preset(name string) Preset {
+	return Preset{
+		Version:        presetVersion,
+		Name:           name,
+		FilterExt:      rp.FilterExt,
+		NameFilter:     rp.NameFilter,
+		RegexFilter:    rp.RegexFilter,
+		MinSize:        rp.MinSize,
+		MaxSize:        rp.MaxSize,
+		ModifiedAfter:  rp.ModifiedAfter,
+		ModifiedBefore: rp.ModifiedBefore,
+		HiddenFilter:   rp.HiddenFilter,
+		ReadOnlyFilter: rp.ReadOnlyFilter,
+		SortMode:       rp.SortMode,
+		SortDescending: rp.SortDescending,
+		Target:         rp.Target,
+		Recursive:      rp.Recursive,
+		MaxDepth:       rp.MaxDepth,
+		IncludeFolders: rp.IncludeFolders,
+		ExcludeFolders: rp.ExcludeFolders,
+		Rules:          append([]RenameRule{}, rp.Rules...),
+		ConflictPolicy: rp.ConflictPolicy,
+		NumberPattern:  rp.NumberPattern,
+		FailureMode:    rp.FailureMode,
+	}
+}
+
+// Replace the current settings with the preset, settings missing from the file keep their default
+func (rp *RenamerProcessor) ApplyPreset(preset Preset) {
+	defaults := NewRenamerProcessor()
+	rp.FilterExt = preset.FilterExt
+	rp.NameFilter = preset.NameFilter
+	rp.RegexFilter = preset.RegexFilter
+	rp.MinSize = preset.MinSize
+	rp.MaxSize = preset.MaxSize
+	rp.ModifiedAfter = preset.ModifiedAfter
+	rp.ModifiedBefore = preset.ModifiedBefore
+	rp.HiddenFilter = cmp.Or(preset.HiddenFilter, defaults.HiddenFilter)
+	rp.ReadOnlyFilter = cmp.Or(preset.ReadOnlyFilter, defaults.ReadOnlyFilter)
+	rp.SortMode = cmp.Or(preset.SortMode, defaults.SortMode)
+	rp.SortDescending = preset.SortDescending
+	rp.Target = cmp.Or(preset.Target, defaults.Target)
+	rp.Recursive = preset.Recursive
+	rp.MaxDepth = preset.MaxDepth
+	rp.IncludeFolders = preset.IncludeFolders
+	rp.ExcludeFolders = preset.ExcludeFolders
+	rp.Rules = slices.Clone(preset.Rules)
+	rp.ConflictPolicy = cmp.Or(preset.ConflictPolicy, defaults.ConflictPolicy)
+	rp.NumberPattern = cmp.Or(preset.NumberPattern, defaults.NumberPattern)
+	rp.FailureMode = cmp.Or(preset.FailureMode, defaults.FailureMode)
+}
+
+// Check that the preset only uses settings this version understands
+func (p Preset) Validate() error {
+	if p.Version > presetVersion {
+		return fmt.Errorf("preset was saved by a newer version (format %d)", p.Version)
+	}
+	if p.Target != "" && !slices.Contains(Targets, p.Target) {
+		return fmt.Errorf("unknown target %q", p.Target)
+	}
+	for _, mode := range []string{p.HiddenFilter, p.ReadOnlyFilter} {
+		if mode != "" && !slices.Contains(AttributeFilters, mode) {
+			return fmt.Errorf("unknown attribute filter %q", mode)
+		}
+	}
+	if p.SortMode != "" && !slices.Contains(SortModes, p.SortMode) {
+		return fmt.Errorf("unknown sort mode %q", p.SortMode)
+	}
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("max depth must not be negative")
+	}
+	if p.NumberPattern != "" {
+		if err := ValidateNumberPattern(p.NumberPattern); err != nil {
+			return err
+		}
+	}
+	if p.ConflictPolicy != "" && !slices.Contains(ConflictPolicies, p.ConflictPolicy) {
+		return fmt.Errorf("unknown conflict policy %q", p.ConflictPolicy)
+	}
+	if p.FailureMode != "" && !slices.Contains(FailureModes, p.FailureMode) {
+		return fmt.Errorf("unknown failure mode %q", p.FailureMode)
+	}
+	filters := NewRenamerProcessor()
+	filters.ApplyPreset(p)
+	if err := filters.ValidateFilters(); err != nil {
+		return err
+	}
+	for i, rule := range p.Rules {
+		if err := rule.prepare(); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, rule.Type, err)
+		}
+	}
+	return nil
+}
+
+// Read and validate a preset
+func ReadPreset(r io.Reader) (Preset, error) {
+	var preset Preset
+	if err := json.NewDecoder(r).Decode(&preset); err != nil {
+		return Preset{}, fmt.Errorf("reading preset: %w", err)
+	}
+	if err := preset.Validate(); err != nil {
+		return Preset{}, fmt.Errorf("invalid preset: %w", err)
+	}
+	return preset, nil
+}
+
+// Write the preset as indented JSON
+func WritePreset(w io.Writer, preset Preset) error {
+	data, err := json.MarshalIndent(preset, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Load a preset file, the file name is used if the preset has no name
+func LoadPresetFile(path string) (Preset, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Preset{}, err
+	}
+	defer file.Close()
+	preset, err := ReadPreset(file)
+	if err != nil {
+		return Preset{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if preset.Name == "" {
+		preset.Name = PresetName(path)
+	}
+	return preset, nil
+}
+
+// Save a preset file
+func SavePresetFile(path string, preset Preset) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WritePreset(file, preset); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Get the preset name from its file name, e.g. "weekly photos.json" → "weekly photos"
+func PresetName(path string) string {
+	name := filepath.Base(path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
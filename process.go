@@ -1,74 +1,137 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
+// What LoadFiles picks up for renaming
+var Targets = []string{"Files", "Folders", "Both"}
+
 // FileRenamer is a struct that holds the file processing logic
 type RenamerProcessor struct {
-	FolderPath     string        // FolderPath
-	Files          []os.FileInfo // All files in the folder
-	FilteredFiles  []os.FileInfo // Files after filtering
-	FilterExt      string        // Extension filter
-	NewNames       []string      // New names for files
-	PrefixValue    string        // Prefix to add or remove
-	SuffixValue    string        // Suffix to add or remove
-	ExtensionValue string        // Extension to change
-	PrefixMode     string        // "None", "Add", "Remove"
-	SuffixMode     string        // "None", "Add", "Remove"
-	ExtensionMode  string        // "None", "Change"
+	FolderPath    string         // FolderPath
+	Files         []FileEntry    // All files in the folder
+	FilteredFiles []FileEntry    // Files after filtering
+	FilterExt     string         // Extension filter
+	NameFilter    string         // Glob patterns for names, e.g. "IMG_2024*.jpg;not *_thumb.*"
+	RegexFilter   string         // Regular expression the full name must match
+	Target        string         // "Files", "Folders" or "Both"
+	NewNames      []string       // New names for files
+	Statuses      []RenameStatus // Status of each new name, e.g. OK or Duplicate
+	Rules         []RenameRule   // Ordered rename steps, applied one after another
+	LastRenames   []RenameOp     // Renames executed by the last call to RenameFiles
+	Results       []RenameResult // Outcome of each file in the last call to RenameFiles
+	// Advanced filter
+	MinSize        int64     // Smallest file size in bytes, 0 for no limit
+	MaxSize        int64     // Largest file size in bytes, 0 for no limit
+	ModifiedAfter  time.Time // Earliest modification time, zero for no limit
+	ModifiedBefore time.Time // Modification time must be before this, zero for no limit
+	HiddenFilter   string    // "Include", "Exclude" or "Only" hidden files and dotfiles
+	ReadOnlyFilter string    // "Include", "Exclude" or "Only" read-only files
+	// Order
+	SortMode       string   // One of SortModes, numbering follows this order
+	SortDescending bool     // Reverse the order
+	ManualOrder    []string // Paths in the order set by hand, used by the "Manual" sort mode
+	// Selection
+	Excluded  map[string]bool   // Paths of the files unchecked in the table, they keep their name
+	Overrides map[string]string // New names typed by hand, keyed by path, they replace the names of the steps
+	// Subfolders
+	Recursive      bool   // Also load the files of subfolders
+	MaxDepth       int    // Deepest subfolder level to load, 0 for no limit
+	IncludeFolders string // Only enter subfolders matching these patterns, e.g. "2024*;raw"
+	ExcludeFolders string // Never enter subfolders matching these patterns, e.g. ".git;thumbs"
+	// Conflict handling
+	ConflictPolicy string // "Abort", "Skip", "Number"
+	NumberPattern  string // Pattern added by the Number policy, e.g. " ({n})"
+	FailureMode    string // "Stop" keeps finished renames, "Rollback" reverts them, "Continue" renames the other files
 
+	moving map[string]bool       // Old names of the files leaving their name in this batch
+	names  nameCache             // New names of the last preview, reused for files whose name can't have changed
+	stats  map[string]statResult // Paths looked up on disk while the conflicts are checked
+	stop   *atomic.Bool          // Set once the names are no longer needed, e.g. a newer preview started
 }
 
 // Create new RenamerProcessor instance
 func NewRenamerProcessor() *RenamerProcessor {
-	return &RenamerProcessor{}
+	return &RenamerProcessor{
+		Target:         "Files",
+		SortMode:       "Name",
+		HiddenFilter:   "Include",
+		ReadOnlyFilter: "Include",
+		ConflictPolicy: "Abort",
+		NumberPattern:  NumberPatterns[0],
+		FailureMode:    "Stop",
+	}
 }
 
-// Filter the files based on the specified extension
+// Filter the files based on the specified extension, name patterns and regular expression, then sort them
 func (rp *RenamerProcessor) FilterFiles() {
-	rp.FilteredFiles = make([]os.FileInfo, 0)
+	rp.FilteredFiles = make([]FileEntry, 0)
+	// Sort the files once they are filtered
+	defer rp.sortFiles()
+	// An invalid expression or pattern matches nothing, ValidateFilters tells why
+	if rp.ValidateFilters() != nil {
+		return
+	}
 	// If no filter is set, copy all files to filtered files
-	if rp.FilterExt == "" {
-		rp.FilteredFiles = rp.Files
+	if rp.FilterExt == "" && strings.TrimSpace(rp.NameFilter) == "" && rp.RegexFilter == "" && !rp.hasAdvancedFilter() {
+		rp.FilteredFiles = slices.Clone(rp.Files)
 		return
 	}
+	re := regexp.MustCompile(rp.RegexFilter)
 	// Split the filter extensions by semicolon and process each
 	exts := strings.Split(rp.FilterExt, ";")
 	for _, file := range rp.Files {
-		// Check if the file matches any of the specified extensions
-		fileExt := strings.ToLower(filepath.Ext(file.Name()))
-		for _, ext := range exts {
-			ext = strings.ToLower(strings.TrimSpace(ext))
-			// Ensure the extension starts with a dot
-			if ext != "" && !strings.HasPrefix(ext, ".") {
-				ext = "." + ext
-			}
-			// If the file's extension matches the filter, add it to the filtered files
-			if ext == fileExt {
-				rp.FilteredFiles = append(rp.FilteredFiles, file)
-				break
-			}
+		// The extension filter only applies to files
+		if rp.FilterExt != "" && !file.IsDir() && !matchesExtension(file.Name(), exts) {
+			continue
 		}
+		if !matchesNameFilter(file.Name(), rp.NameFilter) || !re.MatchString(file.Name()) || !rp.matchesAdvancedFilter(file) {
+			continue
+		}
+		rp.FilteredFiles = append(rp.FilteredFiles, file)
 	}
 }
 
 // Load files rpom the specified path into the RenamerProcessor
 func (rp *RenamerProcessor) LoadFiles(path string) error {
+	// The order and the selection set by hand belong to the folder they were set in
+	if path != rp.FolderPath {
+		rp.ManualOrder = nil
+		rp.Excluded = nil
+		rp.Overrides = nil
+	}
 	rp.FolderPath = path
 	rp.Files = nil
 	rp.FilteredFiles = nil
 
+	// Walk the subfolders if requested
+	if rp.Recursive {
+		files, err := rp.walkFiles(path)
+		if err != nil {
+			return err
+		}
+		rp.Files = files
+		rp.FilterFiles()
+		return nil
+	}
 	files, err := os.ReadDir(path)
 	if err != nil {
 		return err
 	}
 	// Initialize the Files slice
 	for _, file := range files {
-		// Check if the file is a directory, if so, skip it
-		if file.IsDir() {
+		// Skip files or folders that are not renamed
+		if !rp.isTarget(file.IsDir()) {
 			continue
 		}
 		// Get file info and append to the Files slice if no error
@@ -76,90 +139,263 @@ func (rp *RenamerProcessor) LoadFiles(path string) error {
 		if err != nil {
 			continue
 		}
-		rp.Files = append(rp.Files, info)
+		rp.Files = append(rp.Files, FileEntry{FileInfo: info})
 	}
 	// Filter the files after loading
 	rp.FilterFiles()
 	return nil
 }
 
-// Generate new names for the filtered files based on the specified prefix, suffix, and extension
-func (rp *RenamerProcessor) GenerateNewNames() {
+// Check whether files or folders are renamed
+func (rp *RenamerProcessor) isTarget(isDir bool) bool {
+	if isDir {
+		return rp.Target == "Folders" || rp.Target == "Both"
+	}
+	return rp.Target != "Folders"
+}
+
+// Include the file in the batch or exclude it, an excluded file keeps its name
+func (rp *RenamerProcessor) SetExcluded(file FileEntry, excluded bool) {
+	if !excluded {
+		delete(rp.Excluded, file.Path())
+		return
+	}
+	if rp.Excluded == nil {
+		rp.Excluded = make(map[string]bool)
+	}
+	rp.Excluded[file.Path()] = true
+}
+
+// Set the new name of the file by hand, an empty name uses the steps again
+func (rp *RenamerProcessor) SetOverride(file FileEntry, newName string) {
+	if newName == "" {
+		delete(rp.Overrides, file.Path())
+		return
+	}
+	if rp.Overrides == nil {
+		rp.Overrides = make(map[string]string)
+	}
+	rp.Overrides[file.Path()] = newName
+}
+
+// Count the included filtered files whose new name was typed by hand
+func (rp *RenamerProcessor) OverrideCount() int {
+	count := 0
+	for _, file := range rp.FilteredFiles {
+		if _, ok := rp.Overrides[file.Path()]; ok && !rp.Excluded[file.Path()] {
+			count++
+		}
+	}
+	return count
+}
+
+// Forget the names typed by hand for the files that got them
+func (rp *RenamerProcessor) dropOverrides() {
+	for _, result := range rp.Results {
+		if result.Outcome == "Renamed" {
+			delete(rp.Overrides, result.Old)
+		}
+	}
+}
+
+// Count the filtered files that are not excluded
+func (rp *RenamerProcessor) IncludedCount() int {
+	count := 0
+	for _, file := range rp.FilteredFiles {
+		if !rp.Excluded[file.Path()] {
+			count++
+		}
+	}
+	return count
+}
+
+// Add a new step to the end of the pipeline
+func (rp *RenamerProcessor) AddRule(rule RenameRule) {
+	rp.Rules = append(rp.Rules, rule)
+}
+
+// Remove the step at the given index
+func (rp *RenamerProcessor) RemoveRule(index int) {
+	if index < 0 || index >= len(rp.Rules) {
+		return
+	}
+	rp.Rules = append(rp.Rules[:index], rp.Rules[index+1:]...)
+}
+
+// Move the step at the given index up (negative offset) or down (positive offset)
+func (rp *RenamerProcessor) MoveRule(index, offset int) {
+	target := index + offset
+	if index < 0 || index >= len(rp.Rules) || target < 0 || target >= len(rp.Rules) {
+		return
+	}
+	rp.Rules[index], rp.Rules[target] = rp.Rules[target], rp.Rules[index]
+}
+
+// Generate new names for the filtered files by running every rule in order
+func (rp *RenamerProcessor) GenerateNewNames() error {
 	// Initialize the NewNames slice with the same length as FilteredFiles
 	if len(rp.FilteredFiles) == 0 {
 		rp.NewNames = nil
-		return
+		rp.Statuses = nil
+		return nil
+	}
+	if rp.ConflictPolicy == "Number" {
+		if err := ValidateNumberPattern(rp.NumberPattern); err != nil {
+			rp.NewNames = nil
+			rp.Statuses = nil
+			return err
+		}
+	}
+	// Check every rule before touching any names, so an invalid rule produces no names at all
+	for i := range rp.Rules {
+		if err := rp.Rules[i].prepare(); err != nil {
+			rp.NewNames = nil
+			rp.Statuses = nil
+			return fmt.Errorf("step %d (%s): %w", i+1, rp.Rules[i].Type, err)
+		}
 	}
 	rp.NewNames = make([]string, len(rp.FilteredFiles))
+	// Names of the last preview are reused, only files that are new or moved to another number are renamed again
+	cached := rp.cachedNames()
+	names := make(map[nameCacheKey]string, len(rp.FilteredFiles))
+	usesIndex := rp.usesIndex()
 
+	index := 0 // Position among the included files, used by numbering
 	for i, file := range rp.FilteredFiles {
-		oldName := file.Name()
-		newName := oldName // Edit the name based on the old name
-		// Edit prefix according to the specified mode
-		switch rp.PrefixMode {
-		case "Add":
-			newName = rp.PrefixValue + newName
-		case "Remove":
-			newName = strings.TrimPrefix(newName, rp.PrefixValue)
-		}
-		// Edit suffix according to the specified mode
-		switch rp.SuffixMode {
-		case "Add":
-			ext := filepath.Ext(newName)
-			base := strings.TrimSuffix(newName, ext)
-			newName = base + rp.SuffixValue + ext
-		case "Remove":
-			ext := filepath.Ext(newName)
-			base := strings.TrimSuffix(newName, ext)
-			if strings.HasSuffix(base, rp.SuffixValue) {
-				base = strings.TrimSuffix(base, rp.SuffixValue)
-				newName = base + ext
-			}
-		}
-		switch rp.ExtensionMode {
-		case "Change":
-			if rp.ExtensionValue != "" {
-				ext := filepath.Ext(newName)
-				newExt := rp.ExtensionValue
-				// Ensure the new extension starts with a dot
-				if !strings.HasPrefix(newExt, ".") {
-					newExt = "." + newExt
-				}
-				// If the old name has an extension, replace it with the new extension
-				// If the old name has no extension, just append the new extension
-				if ext != "" {
-					newName = strings.TrimSuffix(newName, ext) + newExt
-				} else {
-					newName += newExt
-				}
-
-			}
+		if rp.stopped() {
+			return errPreviewStopped
+		}
+		newName := file.Name() // Edit the name based on the old name
+		// Excluded files keep their name and take no number
+		if rp.Excluded[file.Path()] {
+			rp.NewNames[i] = newName
+			continue
+		}
+		ctx := RuleContext{Index: index, File: file, Dir: filepath.Join(rp.FolderPath, file.Dir)}
+		index++
+		// A name typed by hand replaces the steps, the other files keep their numbers
+		if override, ok := rp.Overrides[file.Path()]; ok {
+			rp.NewNames[i] = override
+			continue
+		}
+		key := nameCacheKey{path: file.Path(), size: file.Size(), modTime: file.ModTime()}
+		if usesIndex {
+			key.index = ctx.Index
+		}
+		if name, ok := cached[key]; ok {
+			rp.NewNames[i] = name
+			names[key] = name
+			continue
+		}
+		// Each rule sees the output of the previous one
+		for j := range rp.Rules {
+			newName = rp.Rules[j].Apply(newName, ctx)
 		}
 		// Store the new name in the NewNames slice
 		rp.NewNames[i] = newName
+		names[key] = newName
 	}
+	rp.names.names = names
+	// Mark duplicates, existing files and invalid names, then skip or renumber them if requested
+	rp.checkConflicts()
+	if rp.stopped() {
+		return errPreviewStopped
+	}
+	return nil
 }
 
+// Returned by GenerateNewNames when the names were no longer needed before they were finished
+var errPreviewStopped = errors.New("preview stopped")
+
+// Check whether the names are no longer needed
+func (rp *RenamerProcessor) stopped() bool {
+	return rp.stop != nil && rp.stop.Load()
+}
+
+// Copy the files and settings the new names are generated from, so a preview can run in the background
+// while they change. The copy gives up as soon as stop is set.
+func (rp *RenamerProcessor) previewCopy(stop *atomic.Bool) *RenamerProcessor {
+	preview := *rp
+	preview.FilteredFiles = slices.Clone(rp.FilteredFiles)
+	preview.Rules = slices.Clone(rp.Rules)
+	preview.Excluded = maps.Clone(rp.Excluded)
+	preview.Overrides = maps.Clone(rp.Overrides)
+	preview.stop = stop
+	return &preview
+}
+
+// Rename the filtered files to their new names
 func (rp *RenamerProcessor) RenameFiles() (int, error) {
-	successCount := 0 // Ensure that NewNames is generated before renaming
+	rp.LastRenames = nil
+	rp.Results = nil
+	// Never start a batch that would fail midway or overwrite files
+	if len(rp.Statuses) != len(rp.NewNames) || len(rp.NewNames) != len(rp.FilteredFiles) {
+		return 0, fmt.Errorf("preview is out of date, generate it again")
+	}
+	if conflicts := rp.ConflictCount(); conflicts > 0 {
+		return 0, fmt.Errorf("%d files have conflicting names", conflicts)
+	}
+	// The old names of the renamed files are free for the batch, the preview may have been generated on a copy
+	rp.moving = make(map[string]bool, len(rp.FilteredFiles))
 	for i, file := range rp.FilteredFiles {
-		oldPath := filepath.Join(rp.FolderPath, file.Name())    // Combine folder path and old file name
-		newPath := filepath.Join(rp.FolderPath, rp.NewNames[i]) // Combine folder path and new file name
-		// Skip renaming if the old and new paths are the same
-		if oldPath == newPath {
-			continue
+		if rp.Statuses[i].moves() {
+			rp.moving[nameKey(file.Path())] = true
 		}
-		// Rename the file and check for errors
-		err := os.Rename(oldPath, newPath)
-		if err != nil {
-			return successCount, err // If error occurs, return the count and error
+	}
+	// Check the disk again, files may have been created since the preview
+	for i, file := range rp.FilteredFiles {
+		if rp.Statuses[i].moves() && rp.targetExists(file, rp.NewNames[i]) {
+			return 0, fmt.Errorf("%s already exists, generate the preview again", rp.NewNames[i])
 		}
-		successCount++ // If no error occurs, increse the success count
 	}
-	// Reload the Files and check for any errors
-	if err := rp.LoadFiles(rp.FolderPath); err != nil {
-		return successCount, err
+	// Swaps and shifted sequences are renamed through temporary names
+	applied, err := executeRenames(rp.FolderPath, rp.plannedRenames(), rp.FailureMode)
+	rp.LastRenames = finalRenames(applied) // Keep the executed renames so they can be undone
+	rp.collectResults(applied, err)
+	rp.renameManualOrder(rp.LastRenames)
+	rp.dropOverrides()
+	// Reload the Files, the folder has changed even if the batch failed
+	if loadErr := rp.LoadFiles(rp.FolderPath); loadErr != nil && err == nil {
+		err = loadErr
 	}
 	// Return the count of successfully renamed files
-	return successCount, nil
+	return len(applied), err
+}
+
+// Record the outcome of every file that was not left unchanged
+func (rp *RenamerProcessor) collectResults(applied []RenameOp, err error) {
+	renamed := make(map[string]bool, len(applied)) // Keyed by the old path
+	for _, op := range applied {
+		renamed[op.Old] = true
+	}
+	// Collect the reason of every failed rename
+	failures := make(map[string]error)
+	var renameErr *RenameError
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		for _, failure := range batchErr.Failures {
+			failures[failure.Op.Old] = failure.Err
+		}
+	} else if errors.As(err, &renameErr) {
+		failures[renameErr.Op.Old] = renameErr.Err
+	}
+	for i, file := range rp.FilteredFiles {
+		path := file.Path()
+		result := RenameResult{Old: path, New: file.NewPath(rp.NewNames[i])}
+		switch {
+		case rp.Statuses[i] == StatusUnchanged || rp.Statuses[i] == StatusExcluded:
+			continue
+		case rp.Statuses[i] == StatusSkipped:
+			result.Outcome, result.Reason = "Skipped", "name conflict"
+		case renamed[path]:
+			result.Outcome = "Renamed"
+		case failures[path] != nil:
+			result.Outcome, result.Reason = "Failed", failures[path].Error()
+		case renameErr != nil && renameErr.RolledBack:
+			result.Outcome, result.Reason = "Skipped", "batch rolled back"
+		default:
+			result.Outcome, result.Reason = "Skipped", "batch stopped after an error"
+		}
+		rp.Results = append(rp.Results, result)
+	}
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestRenameAfterBackgroundPreview(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "1.txt", "2.txt")
+	rp := NewRenamerProcessor()
+	rule := NewRenameRule("Number")
+	rule.Mode = "Replace"
+	rule.Start = 2
+	rule.Padding = 1
+	rp.AddRule(rule)
+	if err := rp.LoadFiles(folder); err != nil {
+		t.Fatal(err)
+	}
+	// The live preview generates the names on a copy and only takes its names and statuses
+	preview := rp.previewCopy(new(atomic.Bool))
+	if err := preview.GenerateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+	rp.NewNames, rp.Statuses = preview.NewNames, preview.Statuses
+	if count, err := rp.RenameFiles(); err != nil || count != 2 {
+		t.Fatalf("renamed %d files (%v), want 2", count, err)
+	}
+	checkFiles(t, folder, map[string]string{"2.txt": "1.txt", "3.txt": "2.txt"})
+	checkEntries(t, folder, "2.txt", "3.txt")
+}
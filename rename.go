@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// RenameOp is one planned rename, paths are relative to the folder
+type RenameOp struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Modes for a rename that fails midway through a batch
+var FailureModes = []string{"Stop", "Rollback", "Continue"}
+
+// RenameResult is the outcome of one file of the last batch
+type RenameResult struct {
+	Old     string
+	New     string
+	Outcome string // "Renamed", "Skipped", "Failed"
+	Reason  string // Why the file was skipped or failed
+}
+
+// RenameError tells which file made a batch fail, and whether the finished renames were rolled back
+type RenameError struct {
+	Op          RenameOp // Rename that failed
+	Err         error    // Why it failed
+	RolledBack  bool     // All finished renames of the batch were reverted
+	RollbackErr error    // Why reverting failed, if it did
+	Stranded    []string // Files left under a temporary name, as "old name (temporary name)"
+}
+
+func (e *RenameError) Error() string {
+	message := fmt.Sprintf("renaming %s to %s failed: %v", e.Op.Old, e.Op.New, e.Err)
+	if e.RolledBack {
+		message += "; all renames of the batch were rolled back"
+	} else if e.RollbackErr != nil {
+		message += fmt.Sprintf("; rollback failed: %v", e.RollbackErr)
+	}
+	if len(e.Stranded) > 0 {
+		message += "; left under a temporary name: " + strings.Join(e.Stranded, ", ")
+	}
+	return message
+}
+
+func (e *RenameError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError collects every failed rename of a batch run in Continue mode
+type BatchError struct {
+	Failures []*RenameError
+	Stranded []string // Files left under a temporary name, as "old name (temporary name)"
+}
+
+func (e *BatchError) Error() string {
+	message := fmt.Sprintf("%d renames failed, first: %v", len(e.Failures), e.Failures[0])
+	if len(e.Stranded) > 0 {
+		message += "; left under a temporary name: " + strings.Join(e.Stranded, ", ")
+	}
+	return message
+}
+
+// Collect the renames of the current preview, unchanged and skipped files are left out
+func (rp *RenamerProcessor) plannedRenames() []RenameOp {
+	var ops []RenameOp
+	for i, file := range rp.FilteredFiles {
+		if rp.Statuses[i] == StatusOK || rp.Statuses[i] == StatusResolved {
+			ops = append(ops, RenameOp{Old: file.Path(), New: file.NewPath(rp.NewNames[i])})
+		}
+	}
+	return ops
+}
+
+// Execute the renames and return the ones that were applied. Deeper paths are renamed first, so renaming
+// a folder never moves a file that is still waiting for its own rename. On failure "Stop" keeps the applied
+// renames, "Rollback" reverts them and "Continue" goes on with the other files and reports every failure in
+// a BatchError.
+func executeRenames(folder string, ops []RenameOp, mode string) ([]RenameOp, error) {
+	var applied []RenameOp
+	var batchErr BatchError
+	for _, group := range depthGroups(ops) {
+		var err error
+		applied, err = renameGroup(folder, group, mode, applied)
+		if groupErr, ok := err.(*BatchError); ok {
+			batchErr.Failures = append(batchErr.Failures, groupErr.Failures...)
+			batchErr.Stranded = append(batchErr.Stranded, groupErr.Stranded...)
+			continue
+		}
+		if err != nil {
+			return applied, err
+		}
+	}
+	if len(batchErr.Failures) > 0 {
+		return applied, &batchErr
+	}
+	return applied, nil
+}
+
+// Split the renames by the depth of their path, deepest first, keeping their order within each depth
+func depthGroups(ops []RenameOp) [][]RenameOp {
+	byDepth := make(map[int][]RenameOp)
+	maxDepth := 0
+	for _, op := range ops {
+		depth := strings.Count(filepath.Clean(op.Old), string(filepath.Separator))
+		byDepth[depth] = append(byDepth[depth], op)
+		maxDepth = max(maxDepth, depth)
+	}
+	var groups [][]RenameOp
+	for depth := maxDepth; depth >= 0; depth-- {
+		if len(byDepth[depth]) > 0 {
+			groups = append(groups, byDepth[depth])
+		}
+	}
+	return groups
+}
+
+// Execute renames of the same depth, which never change each other's paths, and return done with the applied
+// renames added. Files whose old name is the new name of another file are moved to a temporary name first, so
+// chains (1→2, 2→3) and cycles (a→b, b→a) never collide with themselves. A rollback also reverts done.
+func renameGroup(folder string, ops []RenameOp, mode string, done []RenameOp) ([]RenameOp, error) {
+	// Find the files that must leave their name before another file can take it
+	targets := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		targets[nameKey(op.New)] = true
+	}
+	current := make([]string, len(ops)) // Where each file is right now
+	staged := make([]bool, len(ops))    // Whether the file is waiting under a temporary name
+	failed := make([]bool, len(ops))    // Whether the rename failed in Continue mode
+	applied := done
+	var failures []*RenameError
+	for i, op := range ops {
+		current[i] = op.Old
+	}
+	// Move the files waiting under a temporary name back to their old name
+	restoreStaged := func() []string {
+		var stranded []string
+		for i := range ops {
+			if !staged[i] {
+				continue
+			}
+			if renameNoClobber(filepath.Join(folder, current[i]), filepath.Join(folder, ops[i].Old)) != nil {
+				stranded = append(stranded, fmt.Sprintf("%s (%s)", ops[i].Old, current[i]))
+				continue
+			}
+			current[i] = ops[i].Old
+			staged[i] = false
+		}
+		return stranded
+	}
+	// Record a failure, returns true if the batch must stop
+	fail := func(i int, err error) bool {
+		// The reason is enough, the paths are already part of the message
+		var linkErr *os.LinkError
+		if errors.As(err, &linkErr) {
+			err = linkErr.Err
+		}
+		failures = append(failures, &RenameError{Op: ops[i], Err: err})
+		failed[i] = true
+		return mode != "Continue"
+	}
+	// Stop the batch after the first failure, then revert everything or restore the waiting files
+	stop := func() ([]RenameOp, error) {
+		renameErr := failures[0]
+		if mode == "Rollback" {
+			return rollbackRenames(folder, applied, ops, current, staged, renameErr)
+		}
+		renameErr.Stranded = restoreStaged()
+		return applied, renameErr
+	}
+
+	// Phase 1: move the files in a chain or cycle out of the way
+	for i, op := range ops {
+		if !targets[nameKey(op.Old)] || nameKey(op.Old) == nameKey(op.New) {
+			continue
+		}
+		// Stay in the same subfolder, so the temporary name is on the same drive
+		dir := filepath.Dir(op.Old)
+		temp, err := temporaryName(filepath.Join(folder, dir), i)
+		if err == nil {
+			temp = filepath.Join(dir, temp)
+			err = renameNoClobber(filepath.Join(folder, op.Old), filepath.Join(folder, temp))
+			if err != nil {
+				err = fmt.Errorf("moving to a temporary name: %w", err)
+			}
+		}
+		if err != nil {
+			if fail(i, err) {
+				return stop()
+			}
+			continue
+		}
+		current[i] = temp
+		staged[i] = true
+	}
+	// Phase 2: move every file to its new name
+	for i, op := range ops {
+		if failed[i] {
+			continue
+		}
+		if err := renameNoClobber(filepath.Join(folder, current[i]), filepath.Join(folder, op.New)); err != nil {
+			if fail(i, err) {
+				return stop()
+			}
+			continue
+		}
+		current[i] = op.New
+		staged[i] = false
+		applied = append(applied, op)
+	}
+	if len(failures) > 0 {
+		return applied, &BatchError{Failures: failures, Stranded: restoreStaged()}
+	}
+	return applied, nil
+}
+
+// Rename without replacing another file that already uses the new name, os.Rename would overwrite it on most platforms
+func renameNoClobber(oldPath, newPath string) error {
+	if existing, err := os.Lstat(newPath); err == nil {
+		// A case-only rename finds the file itself on case-insensitive file systems
+		source, err := os.Lstat(oldPath)
+		if err != nil || !os.SameFile(existing, source) {
+			return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: os.ErrExist}
+		}
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// Revert the applied renames and the files waiting under a temporary name, returns the renames still applied
+func rollbackRenames(folder string, applied, ops []RenameOp, current []string, staged []bool, renameErr *RenameError) ([]RenameOp, error) {
+	final := finalRenames(applied)
+	var undo []RenameOp
+	for _, op := range final {
+		undo = append(undo, RenameOp{Old: op.New, New: op.Old})
+	}
+	for i := range ops {
+		if staged[i] {
+			undo = append(undo, RenameOp{Old: current[i], New: ops[i].Old})
+		}
+	}
+	// The reverse batch may contain chains and cycles too
+	reverted, err := executeRenames(folder, undo, "Stop")
+	if err == nil {
+		renameErr.RolledBack = true
+		return nil, renameErr
+	}
+	renameErr.RollbackErr = err
+	// Keep the renames that could not be reverted
+	undone := make(map[string]bool, len(reverted))
+	for _, op := range reverted {
+		undone[op.Old] = true
+	}
+	var remaining []RenameOp
+	for i, op := range applied {
+		if !undone[final[i].New] {
+			remaining = append(remaining, op)
+		}
+	}
+	return remaining, renameErr
+}
+
+// Rewrite applied renames to the paths their files have after the whole batch: a rename inside a folder that
+// was renamed later moves with the folder. Reversing these renames deepest-first restores the old paths.
+func finalRenames(applied []RenameOp) []RenameOp {
+	separator := string(filepath.Separator)
+	final := make([]RenameOp, len(applied))
+	for i, op := range applied {
+		oldParts := strings.Split(filepath.Clean(op.Old), separator)
+		parts := slices.Clone(oldParts)
+		// Each renamed parent folder replaces one part of the path, found by its path before the batch
+		for _, folder := range applied {
+			depth := strings.Count(filepath.Clean(folder.Old), separator)
+			if depth < len(parts)-1 && filepath.Clean(folder.Old) == filepath.Join(oldParts[:depth+1]...) {
+				parts[depth] = filepath.Base(folder.New)
+			}
+		}
+		dir := filepath.Join(parts[:len(parts)-1]...)
+		final[i] = RenameOp{Old: filepath.Join(dir, filepath.Base(op.Old)), New: filepath.Join(dir, filepath.Base(op.New))}
+	}
+	return final
+}
+
+// Find an unused temporary name in the folder
+func temporaryName(folder string, index int) (string, error) {
+	for attempt := 0; attempt < 100; attempt++ {
+		name := fmt.Sprintf(".batch-renamer-%d-%d-%d.tmp", os.Getpid(), index, attempt)
+		if _, err := os.Lstat(filepath.Join(folder, name)); os.IsNotExist(err) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no free temporary name in %s", folder)
+}
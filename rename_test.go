@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Create files whose content is their name, so a test can tell where each file ended up
+func createFiles(t *testing.T, folder string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(folder, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Check that each path holds the file created under the given name, e.g. {"b.txt": "a.txt"} after a → b
+func checkFiles(t *testing.T, folder string, want map[string]string) {
+	t.Helper()
+	for path, name := range want {
+		data, err := os.ReadFile(filepath.Join(folder, filepath.FromSlash(path)))
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if string(data) != name {
+			t.Errorf("%s holds %s, want %s", path, data, name)
+		}
+	}
+}
+
+// Check that the folder holds exactly these entries, temporary names must not be left behind
+func checkEntries(t *testing.T, folder string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	slices.Sort(want)
+	if !slices.Equal(names, want) {
+		t.Errorf("folder holds %v, want %v", names, want)
+	}
+}
+
+func TestExecuteRenamesSwap(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "a.txt", "b.txt")
+	applied, err := executeRenames(folder, []RenameOp{{"a.txt", "b.txt"}, {"b.txt", "a.txt"}}, "Stop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 {
+		t.Errorf("applied %d renames, want 2", len(applied))
+	}
+	checkFiles(t, folder, map[string]string{"a.txt": "b.txt", "b.txt": "a.txt"})
+	checkEntries(t, folder, "a.txt", "b.txt")
+}
+
+func TestExecuteRenamesShift(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "1.txt", "2.txt", "3.txt")
+	ops := []RenameOp{{"1.txt", "2.txt"}, {"2.txt", "3.txt"}, {"3.txt", "4.txt"}}
+	if _, err := executeRenames(folder, ops, "Stop"); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, folder, map[string]string{"2.txt": "1.txt", "3.txt": "2.txt", "4.txt": "3.txt"})
+	checkEntries(t, folder, "2.txt", "3.txt", "4.txt")
+}
+
+func TestExecuteRenamesRollback(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "a.txt", "b.txt", "c.txt", "taken.txt")
+	// b.txt can't take the name of a file outside the batch, the swap before it is reverted
+	ops := []RenameOp{{"a.txt", "c.txt"}, {"c.txt", "a.txt"}, {"b.txt", "taken.txt"}}
+	applied, err := executeRenames(folder, ops, "Rollback")
+	var renameErr *RenameError
+	if !errors.As(err, &renameErr) {
+		t.Fatalf("got error %v, want a RenameError", err)
+	}
+	if !renameErr.RolledBack || renameErr.Op != ops[2] || !errors.Is(err, os.ErrExist) {
+		t.Errorf("got %+v, want the rename of b.txt rolled back with ErrExist", renameErr)
+	}
+	if len(applied) != 0 {
+		t.Errorf("applied %v after the rollback, want none", applied)
+	}
+	checkFiles(t, folder, map[string]string{"a.txt": "a.txt", "b.txt": "b.txt", "c.txt": "c.txt", "taken.txt": "taken.txt"})
+	checkEntries(t, folder, "a.txt", "b.txt", "c.txt", "taken.txt")
+}
+
+func TestExecuteRenamesContinue(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "a.txt", "b.txt", "c.txt", "taken.txt")
+	ops := []RenameOp{{"a.txt", "x.txt"}, {"b.txt", "taken.txt"}, {"c.txt", "y.txt"}}
+	applied, err := executeRenames(folder, ops, "Continue")
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("got error %v, want a BatchError", err)
+	}
+	if len(batchErr.Failures) != 1 || batchErr.Failures[0].Op != ops[1] {
+		t.Errorf("got failures %v, want only the rename of b.txt", batchErr.Failures)
+	}
+	if want := []RenameOp{ops[0], ops[2]}; !slices.Equal(applied, want) {
+		t.Errorf("applied %v, want %v", applied, want)
+	}
+	checkFiles(t, folder, map[string]string{"x.txt": "a.txt", "b.txt": "b.txt", "y.txt": "c.txt", "taken.txt": "taken.txt"})
+	checkEntries(t, folder, "b.txt", "taken.txt", "x.txt", "y.txt")
+}
+
+func TestUndoNestedRenames(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "photos/a.jpg", "photos/b.jpg")
+	rp := NewRenamerProcessor()
+	rp.Target = "Both"
+	rp.Recursive = true
+	rp.AddRule(RenameRule{Type: "Prefix", Mode: "Add", Value: "2024_"})
+	if err := rp.LoadFiles(folder); err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.GenerateNewNames(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rp.RenameFiles(); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, folder, map[string]string{"2024_photos/2024_a.jpg": "photos/a.jpg", "2024_photos/2024_b.jpg": "photos/b.jpg"})
+	// The files were renamed before their folder, undo finds them in the renamed folder
+	journal := NewJournal(t.TempDir())
+	if err := journal.Append(folder, rp.LastRenames); err != nil {
+		t.Fatal(err)
+	}
+	report, err := undoLast(t, journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Restored != 3 {
+		t.Errorf("restored %d files, want 3", report.Restored)
+	}
+	checkFiles(t, folder, map[string]string{"photos/a.jpg": "photos/a.jpg", "photos/b.jpg": "photos/b.jpg"})
+	checkEntries(t, folder, "photos")
+	if entry, err := journal.Last(); entry != nil || err != nil {
+		t.Errorf("journal still holds %v (%v) after the undo", entry, err)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Rule types that can be used as steps of the rename pipeline
+var RuleTypes = []string{"Prefix", "Suffix", "Replace", "Regex", "Number", "Template", "Case", "Extension"}
+
+// RenameRule is one step of the rename pipeline, each step edits the output of the previous one
+type RenameRule struct {
+	Type        string `json:"type"`                  // "Prefix", "Suffix", "Replace", "Regex", "Number", "Template", "Case", "Extension"
+	Mode        string `json:"mode,omitempty"`        // "Add", "Remove" for prefix and suffix, "Prefix", "Suffix", "Replace" for number, one of CaseModes for case, "Change", "Remove" for extension
+	Value       string `json:"value,omitempty"`       // Text to add or remove, the template, small words for Title Case, or the new extension
+	Pattern     string `json:"pattern,omitempty"`     // Text or regular expression to search for
+	Replacement string `json:"replacement,omitempty"` // Replacement, regex may reference capture groups like $1 or ${name}
+	IgnoreCase  bool   `json:"ignoreCase,omitempty"`  // Match case-insensitively
+	Scope       string `json:"scope,omitempty"`       // "Base" (name without extension), "Extension" (case only), "Full" (whole file name)
+	Start       int    `json:"start,omitempty"`       // First number of the counter
+	Step        int    `json:"step,omitempty"`        // Increment between two files
+	Padding     int    `json:"padding,omitempty"`     // Minimum digits, padded with zeros
+	Separator   string `json:"separator,omitempty"`   // Text between the number and the name
+
+	re       *regexp.Regexp // Compiled pattern, set by prepare
+	template []templatePart // Parsed template, set by prepare
+}
+
+// RuleContext describes the file a rule is applied to
+type RuleContext struct {
+	Index int         // Position of the file in the batch
+	File  os.FileInfo // Original file
+	Dir   string      // Folder containing the file
+}
+
+// Create a new rule of the given type with default settings
+func NewRenameRule(ruleType string) RenameRule {
+	rule := RenameRule{Type: ruleType, Scope: "Base"}
+	switch ruleType {
+	case "Prefix", "Suffix":
+		rule.Mode = "Add"
+	case "Number":
+		rule.Mode = "Prefix"
+		rule.Start = 1
+		rule.Step = 1
+		rule.Padding = 3
+		rule.Separator = "_"
+	case "Template":
+		rule.Value = "{name}{ext}"
+		rule.Start = 1
+		rule.Step = 1
+	case "Case":
+		rule.Mode = "lower"
+		rule.Value = DefaultSmallWords
+	case "Extension":
+		rule.Mode = "Change"
+	}
+	return rule
+}
+
+// Validate the rule and compile its pattern before applying it to any file
+func (r *RenameRule) prepare() error {
+	r.re = nil
+	r.template = nil
+	switch r.Type {
+	case "Prefix", "Suffix", "Extension":
+		return nil
+	case "Number":
+		if r.Padding < 0 {
+			return fmt.Errorf("padding must not be negative")
+		}
+		return nil
+	case "Case":
+		if !slices.Contains(CaseModes, r.Mode) {
+			return fmt.Errorf("unknown case %q", r.Mode)
+		}
+		return nil
+	case "Template":
+		if r.Value == "" {
+			return fmt.Errorf("template is empty")
+		}
+		parts, err := parseTemplate(r.Value)
+		if err != nil {
+			return err
+		}
+		r.template = parts
+		return nil
+	case "Replace":
+		if r.Pattern == "" {
+			return fmt.Errorf("search text is empty")
+		}
+		if r.IgnoreCase {
+			r.re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(r.Pattern))
+		}
+		return nil
+	case "Regex":
+		if r.Pattern == "" {
+			return fmt.Errorf("regex pattern is empty")
+		}
+		pattern := r.Pattern
+		if r.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		r.re = re
+		return nil
+	}
+	return fmt.Errorf("unknown rule type %q", r.Type)
+}
+
+// Apply the rule to a file name and return the edited name
+func (r *RenameRule) Apply(name string, ctx RuleContext) string {
+	switch r.Type {
+	case "Prefix":
+		switch r.Mode {
+		case "Add":
+			return r.Value + name
+		case "Remove":
+			return strings.TrimPrefix(name, r.Value)
+		}
+	case "Suffix":
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		switch r.Mode {
+		case "Add":
+			return base + r.Value + ext
+		case "Remove":
+			return strings.TrimSuffix(base, r.Value) + ext
+		}
+	case "Replace", "Regex":
+		return r.applyScoped(name, func(s string) string {
+			if r.re == nil {
+				return strings.ReplaceAll(s, r.Pattern, r.Replacement)
+			}
+			if r.Type == "Replace" {
+				return r.re.ReplaceAllLiteralString(s, r.Replacement)
+			}
+			return r.re.ReplaceAllString(s, r.Replacement)
+		})
+	case "Number":
+		number := r.FormatNumber(ctx.Index)
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		switch r.Mode {
+		case "Prefix":
+			return number + r.Separator + name
+		case "Suffix":
+			return base + r.Separator + number + ext
+		case "Replace":
+			return number + ext
+		}
+	case "Template":
+		return expandTemplate(r.template, name, ctx, r)
+	case "Case":
+		ext := filepath.Ext(name)
+		base := strings.TrimSuffix(name, ext)
+		smallWords := ParseSmallWords(r.Value)
+		// Convert the extension without its dot, so the dot is kept
+		if r.Scope != "Base" && ext != "" {
+			ext = "." + ConvertCase(ext[1:], r.Mode, smallWords)
+		}
+		if r.Scope != "Extension" {
+			base = ConvertCase(base, r.Mode, smallWords)
+		}
+		return base + ext
+	case "Extension":
+		ext := filepath.Ext(name)
+		switch r.Mode {
+		case "Change":
+			if r.Value == "" {
+				return name
+			}
+			// Ensure the new extension starts with a dot
+			newExt := r.Value
+			if !strings.HasPrefix(newExt, ".") {
+				newExt = "." + newExt
+			}
+			// Replace the old extension, or append the new one if there is none
+			return strings.TrimSuffix(name, ext) + newExt
+		case "Remove":
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// Run edit on the part of the name selected by the rule's scope
+func (r *RenameRule) applyScoped(name string, edit func(string) string) string {
+	if r.Scope == "Full" {
+		return edit(name)
+	}
+	ext := filepath.Ext(name)
+	return edit(strings.TrimSuffix(name, ext)) + ext
+}
+
+// Format the counter value of the file at the given position in the batch, padded with zeros
+func (r *RenameRule) FormatNumber(index int) string {
+	return fmt.Sprintf("%0*d", r.Padding, r.Start+index*r.Step)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Orders of the filtered files, numbers and templates follow this order
+var SortModes = []string{"Name", "Natural", "Modified", "Size", "Extension", "Manual"}
+
+// Sort the filtered files by the sort mode, ties keep the natural order of their paths
+func (rp *RenamerProcessor) sortFiles() {
+	// Files placed by hand keep their place, new files follow them
+	positions := make(map[string]int, len(rp.ManualOrder))
+	for i, path := range rp.ManualOrder {
+		positions[path] = i
+	}
+	position := func(file FileEntry) int {
+		if i, ok := positions[file.Path()]; ok {
+			return i
+		}
+		return len(positions)
+	}
+	compare := func(a, b FileEntry) int {
+		switch rp.SortMode {
+		case "Manual":
+			return cmp.Compare(position(a), position(b))
+		case "Name":
+			return strings.Compare(a.Path(), b.Path())
+		case "Modified":
+			return a.ModTime().Compare(b.ModTime())
+		case "Size":
+			return cmp.Compare(fileSize(a), fileSize(b))
+		case "Extension":
+			return strings.Compare(strings.ToLower(filepath.Ext(a.Name())), strings.ToLower(filepath.Ext(b.Name())))
+		}
+		return 0
+	}
+	slices.SortStableFunc(rp.FilteredFiles, func(a, b FileEntry) int {
+		order := cmp.Or(compare(a, b), naturalCompare(a.Path(), b.Path()), strings.Compare(a.Path(), b.Path()))
+		// The manual order is never reversed, the rows are moved instead
+		if rp.SortDescending && rp.SortMode != "Manual" {
+			return -order
+		}
+		return order
+	})
+}
+
+// Move the filtered file at the given index up (negative offset) or down (positive offset), the files then keep
+// the order set by hand
+func (rp *RenamerProcessor) MoveFile(index, offset int) bool {
+	target := index + offset
+	if index < 0 || index >= len(rp.FilteredFiles) || target < 0 || target >= len(rp.FilteredFiles) {
+		return false
+	}
+	rp.FilteredFiles[index], rp.FilteredFiles[target] = rp.FilteredFiles[target], rp.FilteredFiles[index]
+	rp.KeepOrder()
+	return true
+}
+
+// Switch to the manual order, starting from the current order of the filtered files
+func (rp *RenamerProcessor) KeepOrder() {
+	order := make([]string, 0, len(rp.FilteredFiles))
+	listed := make(map[string]bool, len(rp.FilteredFiles))
+	for _, file := range rp.FilteredFiles {
+		order = append(order, file.Path())
+		listed[file.Path()] = true
+	}
+	// Files hidden by the filter keep their manual place after the visible ones
+	for _, path := range rp.ManualOrder {
+		if !listed[path] {
+			order = append(order, path)
+		}
+	}
+	rp.ManualOrder = order
+	rp.SortMode = "Manual"
+}
+
+// Keep the manual order of the renamed files under their new paths
+func (rp *RenamerProcessor) renameManualOrder(renames []RenameOp) {
+	newPaths := make(map[string]string, len(renames))
+	for _, op := range renames {
+		newPaths[op.Old] = op.New
+	}
+	for i, path := range rp.ManualOrder {
+		if newPath, ok := newPaths[path]; ok {
+			rp.ManualOrder[i] = newPath
+		}
+	}
+}
+
+// Get the size used for sorting, folders have no size
+func fileSize(file FileEntry) int64 {
+	if file.IsDir() {
+		return 0
+	}
+	return file.Size()
+}
+
+// Compare names so that numbers are ordered by value, "file2" before "file10", ignoring case
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		chunkA, restA := nextChunk(a)
+		chunkB, restB := nextChunk(b)
+		var order int
+		if isDigits(chunkA) && isDigits(chunkB) {
+			// Compare the values without leading zeros, a longer number is larger
+			valueA, valueB := strings.TrimLeft(chunkA, "0"), strings.TrimLeft(chunkB, "0")
+			order = cmp.Or(cmp.Compare(len(valueA), len(valueB)), strings.Compare(valueA, valueB))
+		} else {
+			order = strings.Compare(strings.ToLower(chunkA), strings.ToLower(chunkB))
+		}
+		if order != 0 {
+			return order
+		}
+		a, b = restA, restB
+	}
+	// The name that ran out first is shorter
+	return cmp.Compare(len(a), len(b))
+}
+
+// Split off the leading run of digits or non-digits
+func nextChunk(s string) (string, string) {
+	digits := isDigit(s[0])
+	for i := 1; i < len(s); i++ {
+		if isDigit(s[i]) != digits {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// Check whether the chunk is a number
+func isDigits(chunk string) bool {
+	return chunk != "" && isDigit(chunk[0])
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Help text listing the tokens a template can use
+const TemplateHelp = `Tokens:
+{name}      base name as it reaches this step
+{ext}       extension as it reaches this step, with the dot
+{original}  base name of the original file
+{n}         counter, {n:04} pads it to 4 digits
+{parent}    name of the folder containing the file
+{size}      file size in bytes, {size:kb} or {size:mb} to round
+{mtime}     modification time, {mtime:2006-01-02_1504} sets the Go time layout
+
+Filters: {name|lower}, {name|upper}, {name|title}, {name|snake},
+{name|kebab}, {name|camel}, {name|trim}, can be chained
+Use {{ and }} for literal braces`
+
+// Tokens and filters accepted in templates
+var (
+	templateTokens  = []string{"name", "ext", "original", "n", "parent", "size", "mtime"}
+	templateFilters = []string{"lower", "upper", "title", "snake", "kebab", "camel", "trim"}
+)
+
+// templatePart is either literal text or a token with its format and filters
+type templatePart struct {
+	Literal string
+	Token   string
+	Format  string
+	Filters []string
+}
+
+// Parse a template such as "{parent}_{n:04}{ext}" into parts, reporting unknown tokens
+func parseTemplate(template string) ([]templatePart, error) {
+	var parts []templatePart
+	var literal strings.Builder
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		// Doubled braces are literal braces
+		if (c == '{' || c == '}') && i+1 < len(template) && template[i+1] == c {
+			literal.WriteByte(c)
+			i++
+			continue
+		}
+		if c == '}' {
+			return nil, fmt.Errorf("unexpected } at position %d", i+1)
+		}
+		if c != '{' {
+			literal.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed { at position %d", i+1)
+		}
+		part, err := parseTemplateToken(template[i+1 : i+end])
+		if err != nil {
+			return nil, err
+		}
+		if literal.Len() > 0 {
+			parts = append(parts, templatePart{Literal: literal.String()})
+			literal.Reset()
+		}
+		parts = append(parts, part)
+		i += end
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, templatePart{Literal: literal.String()})
+	}
+	return parts, nil
+}
+
+// Parse the inside of a token, e.g. "n:04" or "name|lower"
+func parseTemplateToken(text string) (templatePart, error) {
+	filters := strings.Split(text, "|")
+	token, format, _ := strings.Cut(strings.TrimSpace(filters[0]), ":")
+	part := templatePart{Token: token, Format: format}
+	if !slices.Contains(templateTokens, token) {
+		return part, fmt.Errorf("unknown token {%s}", text)
+	}
+	// Check the format of the tokens that accept one
+	switch token {
+	case "n":
+		if format != "" {
+			if width, err := strconv.Atoi(format); err != nil || width < 0 {
+				return part, fmt.Errorf("invalid counter width in {%s}", text)
+			}
+		}
+	case "size":
+		if format != "" && format != "kb" && format != "mb" {
+			return part, fmt.Errorf("invalid size unit in {%s}, use kb or mb", text)
+		}
+	case "mtime":
+	default:
+		if format != "" {
+			return part, fmt.Errorf("token {%s} does not take a format", token)
+		}
+	}
+	for _, filter := range filters[1:] {
+		filter = strings.TrimSpace(filter)
+		if !slices.Contains(templateFilters, filter) {
+			return part, fmt.Errorf("unknown filter %q in {%s}", filter, text)
+		}
+		part.Filters = append(part.Filters, filter)
+	}
+	return part, nil
+}
+
+// Expand the parsed template for one file, name is the output of the previous step
+func expandTemplate(parts []templatePart, name string, ctx RuleContext, counter *RenameRule) string {
+	var result strings.Builder
+	for _, part := range parts {
+		if part.Token == "" {
+			result.WriteString(part.Literal)
+			continue
+		}
+		value := templateValue(part, name, ctx, counter)
+		for _, filter := range part.Filters {
+			value = applyTemplateFilter(filter, value)
+		}
+		result.WriteString(value)
+	}
+	return result.String()
+}
+
+// Get the value of a single token
+func templateValue(part templatePart, name string, ctx RuleContext, counter *RenameRule) string {
+	ext := filepath.Ext(name)
+	switch part.Token {
+	case "name":
+		return strings.TrimSuffix(name, ext)
+	case "ext":
+		return ext
+	case "original":
+		if ctx.File == nil {
+			return strings.TrimSuffix(name, ext)
+		}
+		return strings.TrimSuffix(ctx.File.Name(), filepath.Ext(ctx.File.Name()))
+	case "n":
+		width, _ := strconv.Atoi(part.Format)
+		return fmt.Sprintf("%0*d", width, counter.Start+ctx.Index*counter.Step)
+	case "parent":
+		return filepath.Base(ctx.Dir)
+	case "size":
+		if ctx.File == nil {
+			return ""
+		}
+		switch part.Format {
+		case "kb":
+			return strconv.FormatInt((ctx.File.Size()+1023)/1024, 10)
+		case "mb":
+			return strconv.FormatInt((ctx.File.Size()+1024*1024-1)/(1024*1024), 10)
+		}
+		return strconv.FormatInt(ctx.File.Size(), 10)
+	case "mtime":
+		if ctx.File == nil {
+			return ""
+		}
+		layout := part.Format
+		if layout == "" {
+			layout = "2006-01-02"
+		}
+		return ctx.File.ModTime().Format(layout)
+	}
+	return ""
+}
+
+// Apply a filter such as "lower" to a token value
+func applyTemplateFilter(filter, value string) string {
+	switch filter {
+	case "lower":
+		return strings.ToLower(value)
+	case "upper":
+		return strings.ToUpper(value)
+	case "title":
+		return ConvertCase(value, "Title", ParseSmallWords(DefaultSmallWords))
+	case "snake":
+		return ConvertCase(value, "snake_case", nil)
+	case "kebab":
+		return ConvertCase(value, "kebab-case", nil)
+	case "camel":
+		return ConvertCase(value, "camelCase", nil)
+	case "trim":
+		return strings.TrimSpace(value)
+	}
+	return value
+}
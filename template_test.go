@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []templatePart
+	}{
+		{"{parent}_{n:04}{ext}", []templatePart{
+			{Token: "parent"}, {Literal: "_"}, {Token: "n", Format: "04"}, {Token: "ext"},
+		}},
+		{"{name|lower|trim} copy", []templatePart{
+			{Token: "name", Filters: []string{"lower", "trim"}}, {Literal: " copy"},
+		}},
+		{"{{draft}}{mtime:2006-01-02}", []templatePart{
+			{Literal: "{draft}"}, {Token: "mtime", Format: "2006-01-02"},
+		}},
+		{"{size:kb}kb", []templatePart{{Token: "size", Format: "kb"}, {Literal: "kb"}}},
+	}
+	for _, test := range tests {
+		parts, err := parseTemplate(test.template)
+		if err != nil {
+			t.Errorf("%s: %v", test.template, err)
+			continue
+		}
+		if !reflect.DeepEqual(parts, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.template, parts, test.want)
+		}
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	for _, template := range []string{
+		"{unknown}",    // Unknown token
+		"{name",        // Unclosed brace
+		"name}",        // Brace without a token
+		"{n:ab}",       // Counter width is not a number
+		"{size:gb}",    // Unknown size unit
+		"{ext:x}",      // Token without a format
+		"{name|shout}", // Unknown filter
+	} {
+		if _, err := parseTemplate(template); err == nil {
+			t.Errorf("%s: parsed without an error", template)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image/color"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -20,6 +26,7 @@ type MainApp struct {
 	App         fyne.App
 	Window      fyne.Window
 	Processor   *RenamerProcessor
+	Journal     *Journal // Executed batches that can be undone
 	StatusLabel *widget.Label
 	ThemeButton *widget.Button
 	DarkMode    bool
@@ -27,28 +34,49 @@ type MainApp struct {
 	folderButton  *widget.Button
 	previewButton *widget.Button
 	renameButton  *widget.Button
+	undoButton    *widget.Button
+	watchButton   *widget.Button
 	clearButton   *widget.Button
 	exitButton    *widget.Button
 	// File selection and filtering
-	FolderPathLabel        *PathDisplay // Custom PathDisplay to show folder path
-	FolderPathDisplay      *fyne.Container
-	FilterEntry            *widget.Entry
-	OriginalTable          *widget.Table
-	OriginalTableContainer *container.Scroll
-	PreviewTable           *widget.Table
-	PreviewTableContainer  *container.Scroll
-	// Radio groups for operations
-	PrefixRadio    *widget.RadioGroup
-	SuffixRadio    *widget.RadioGroup
-	ExtensionRadio *widget.RadioGroup
-	// Perfix, Suffix, and Extension entries
-	PrefixEntry    *widget.Entry
-	SuffixEntry    *widget.Entry
-	ExtensionEntry *widget.Entry
-	// Containers for operations
-	PrefixContainer    *fyne.Container
-	SuffixContainer    *fyne.Container
-	ExtensionContainer *fyne.Container
+	FolderPathLabel     *PathDisplay // Custom PathDisplay to show folder path
+	FolderPathDisplay   *fyne.Container
+	FilterEntry         *widget.Entry
+	NameFilterEntry     *widget.Entry
+	RegexFilterEntry    *widget.Entry
+	MinSizeEntry        *widget.Entry
+	MaxSizeEntry        *widget.Entry
+	AfterEntry          *widget.Entry
+	BeforeEntry         *widget.Entry
+	HiddenSelect        *widget.Select
+	ReadOnlySelect      *widget.Select
+	TargetSelect        *widget.Select
+	RecursiveCheck      *widget.Check
+	MaxDepthEntry       *widget.Entry
+	IncludeFoldersEntry *widget.Entry
+	ExcludeFoldersEntry *widget.Entry
+	SortSelect          *widget.Select
+	SortOrderButton     *widget.Button
+	moveUpButton        *widget.Button
+	moveDownButton      *widget.Button
+	selectedRow         int           // Row selected in the file table, -1 for none
+	FileTable           *widget.Table // Old names, new names and status of the filtered files
+	FileTableContainer  *container.Scroll
+	// Rename pipeline
+	RuleTypeSelect *widget.Select
+	RulesBox       *fyne.Container
+	// Conflict handling
+	ConflictSelect     *widget.Select
+	NumberPatternEntry *widget.SelectEntry
+	FailureSelect      *widget.Select
+	// Watch mode
+	stopWatch      context.CancelFunc // Stops the running watch, nil when not watching
+	watchLog       []string
+	watchLogList   *widget.List
+	watchLogWindow fyne.Window
+	// Live preview
+	previewTimer *time.Timer  // Generates the preview once the settings stop changing
+	previewStop  *atomic.Bool // Stops the preview running in the background, nil when none is running
 }
 
 // PathDisplay shows the file or folder path in a scrollable text container
@@ -63,8 +91,9 @@ func InitializeApp(app fyne.App, window fyne.Window) *MainApp {
 	return &MainApp{
 		App:       app,
 		Window:    window,
-		Processor: &RenamerProcessor{},
-		DarkMode:  isDark, // Save the dark mode preference
+		Processor: NewRenamerProcessor(),
+		Journal:   NewJournal(app.Storage().RootURI().Path()), // Keep the journal in the app's storage
+		DarkMode:  isDark,                                     // Save the dark mode preference
 	}
 }
 
@@ -87,6 +116,9 @@ func (a *MainApp) MakeUI() {
 
 	// Create about button
 	aboutButton := widget.NewButton("About", func() { a.ShowAbout(a.Window) })
+	// Create preset button, opens a menu to load or save the settings
+	var presetButton *widget.Button
+	presetButton = widget.NewButton("Presets", func() { a.ShowPresetMenu(presetButton) })
 
 	// Set the title of the app
 	title := widget.NewLabel("<Batch Renamer>")
@@ -94,6 +126,7 @@ func (a *MainApp) MakeUI() {
 	TitleContainer := container.NewHBox(
 		title,
 		layout.NewSpacer(),
+		presetButton,
 		aboutButton,
 		a.ThemeButton,
 	)
@@ -102,6 +135,9 @@ func (a *MainApp) MakeUI() {
 	a.folderButton = widget.NewButton("Select Folder", a.SelectFolder)
 	a.previewButton = widget.NewButton("Preview", a.PreviewChanges)
 	a.renameButton = widget.NewButton("Rename Files", a.RunRenameProcess)
+	a.undoButton = widget.NewButton("Undo Last Rename", a.UndoLastRename)
+	importButton := widget.NewButton("Import Names", a.ImportMapping)
+	a.watchButton = widget.NewButton("Watch Folder", a.ToggleWatch)
 	a.clearButton = widget.NewButton("Clear", a.ClearAll)
 	a.exitButton = widget.NewButton("Exit", func() { a.App.Quit() })
 	a.renameButton.Disable() // Disable rename button initially
@@ -110,6 +146,9 @@ func (a *MainApp) MakeUI() {
 		a.folderButton,
 		a.previewButton,
 		a.renameButton,
+		a.undoButton,
+		importButton,
+		a.watchButton,
 		layout.NewSpacer(),
 		a.clearButton,
 		a.exitButton,
@@ -131,222 +170,164 @@ func (a *MainApp) MakeUI() {
 	a.FilterEntry.SetPlaceHolder("e.g. .txt;.jpg (leave empty for all)")
 	a.FilterEntry.OnChanged = func(desiredExtension string) {
 		a.Processor.FilterExt = desiredExtension // Update the filter extension in the processor
-		a.FilterFiles()                          // Call the filter method when the entry changes
-		a.renameButton.Disable()
-		// Clear the preview table when filter changes
-		a.PreviewTable = a.InitializePreviewTable()
-		a.PreviewTableContainer.Content = a.PreviewTable
-		a.PreviewTableContainer.Refresh()
+		a.RefreshFilter()                        // Call the filter method when the entry changes
+	}
+	// Filter by glob patterns and a regular expression on the full name
+	a.NameFilterEntry = widget.NewEntry()
+	a.NameFilterEntry.SetPlaceHolder("Names, e.g. IMG_2024*.jpg;not *_thumb.*")
+	a.NameFilterEntry.OnChanged = func(patterns string) {
+		a.Processor.NameFilter = patterns
+		a.RefreshFilter()
+	}
+	a.RegexFilterEntry = widget.NewEntry()
+	a.RegexFilterEntry.SetPlaceHolder("Regex, e.g. ^IMG_\\d{4}")
+	a.RegexFilterEntry.Validator = func(text string) error {
+		_, err := regexp.Compile(text)
+		return err
+	}
+	a.RegexFilterEntry.OnChanged = func(pattern string) {
+		a.Processor.RegexFilter = pattern
+		a.RefreshFilter()
 	}
+	nameFilterBox := container.NewGridWithColumns(2, a.NameFilterEntry, a.RegexFilterEntry)
+	advancedFilter := a.makeAdvancedFilter()
+
+	// Choose whether files, folders or both are renamed
+	a.TargetSelect = widget.NewSelect(Targets, func(selected string) {
+		a.Processor.Target = selected
+		if a.Processor.FolderPath != "" {
+			a.ReloadFolder()
+			a.SchedulePreview()
+		}
+	})
+	a.TargetSelect.SetSelected(a.Processor.Target)
 	filterBox := container.NewBorder(
 		nil, nil, filterLabel,
-		nil,
+		container.NewHBox(widget.NewLabel("Rename:"), a.TargetSelect),
 		container.NewHScroll(a.FilterEntry),
 	)
 
-	// Create operations area
-	// Create a label for operations
-	operationsLabel := widget.NewLabel("Operations:")
-
-	// Prefix editor
-	// Entry for prefix
-	a.PrefixEntry = widget.NewEntry()
-	// Create a radio group for prefix operations
-	prefixLabel := widget.NewLabel("Prefix:")
-	a.PrefixRadio = widget.NewRadioGroup([]string{"None", "Add", "Remove"}, nil)
-	a.PrefixRadio.Horizontal = true // Make the radio buttons horizontal
-	// Set container for the prefix operations
-	a.PrefixContainer = container.NewBorder(
-		nil, nil,
-		container.NewHBox(prefixLabel, a.PrefixRadio),
-		nil,
-		a.PrefixEntry,
-	)
-	//a.PrefixContainer = container.NewVBox(prefixRow)
-	// Set the onChanged function for the prefix radio group
-	a.PrefixRadio.OnChanged = func(selected string) {
-		if selected == "" {
-			a.PrefixRadio.SetSelected(a.Processor.PrefixMode)
-			return
-		} // Avoid situation where selected is empty
-		a.Processor.PrefixMode = selected
-		if selected == "None" {
-			a.PrefixEntry.Hide()      // Hide the entry if "None" is selected
-			a.PrefixEntry.SetText("") // Clear the entry text
-			a.Processor.PrefixValue = ""
-		} else {
-			a.PrefixEntry.Show()                         // Show the entry for adding/removing prefix
-			a.Processor.PrefixValue = a.PrefixEntry.Text // Update the prefix value in the processor
+	// Choose whether files in subfolders are loaded too
+	a.MaxDepthEntry = widget.NewEntry()
+	a.MaxDepthEntry.SetPlaceHolder("0")
+	a.MaxDepthEntry.Validator = func(text string) error {
+		if depth, err := strconv.Atoi(text); text != "" && (err != nil || depth < 0) {
+			return fmt.Errorf("enter 0 or more levels")
 		}
-		a.PrefixContainer.Refresh()
-		a.renameButton.Disable()
-	}
-	// Set the default selection for prefix radio group
-	a.PrefixRadio.SetSelected("None")
-	a.PrefixEntry.SetPlaceHolder("enter Prefix …")
-	// Update value when prefix entry changes
-	a.PrefixEntry.OnChanged = func(value string) {
-		a.Processor.PrefixValue = value
-		a.renameButton.Disable()
+		return nil
 	}
-
-	// Suffix editor
-	// Entry for suffix
-	a.SuffixEntry = widget.NewEntry()
-	// Create a radio group for suffix operations
-	suffixLabel := widget.NewLabel("Suffix:")
-	// Create a radio group for suffix operations
-	a.SuffixRadio = widget.NewRadioGroup([]string{"None", "Add", "Remove"}, nil)
-	a.SuffixRadio.Horizontal = true // Make the radio buttons horizontal
-	// Set container for the suffix operations
-	a.SuffixContainer = container.NewBorder(
-		nil, nil,
-		container.NewHBox(suffixLabel, a.SuffixRadio),
-		nil,
-		a.SuffixEntry,
-	)
-	// Set the onChanged function for the suffix radio group
-	a.SuffixRadio.OnChanged = func(selected string) {
-		if selected == "" {
-			a.SuffixRadio.SetSelected(a.Processor.SuffixMode)
+	a.MaxDepthEntry.OnChanged = func(text string) {
+		depth, err := strconv.Atoi(text)
+		if (err != nil && text != "") || depth < 0 {
 			return
-		} // Avoid situation where selected is empty
-		a.Processor.SuffixMode = selected
-		if selected == "None" {
-			a.SuffixEntry.Hide()      // Hide the entry if "None" is selected
-			a.SuffixEntry.SetText("") // Clear the entry text
-			a.Processor.SuffixValue = ""
-		} else {
-			a.SuffixEntry.Show()                         // Show the entry for adding/removing suffix
-			a.Processor.SuffixValue = a.SuffixEntry.Text // Update the suffix value in the processor
-		}
-		if a.SuffixContainer != nil {
-			a.SuffixContainer.Refresh()
-			a.renameButton.Disable()
 		}
+		a.Processor.MaxDepth = depth
+		a.ReloadSubfolders()
 	}
-	// Set the default selection for suffix radio group
-	a.SuffixRadio.SetSelected("None")
-	a.SuffixEntry.SetPlaceHolder("enter Suffix …")
-	// Update value when suffix entry changes
-	a.SuffixEntry.OnChanged = func(value string) {
-		a.Processor.SuffixValue = value
-		a.renameButton.Disable()
+	a.IncludeFoldersEntry = widget.NewEntry()
+	a.IncludeFoldersEntry.SetPlaceHolder("Only folders, e.g. 2024*;raw")
+	a.IncludeFoldersEntry.OnChanged = func(patterns string) {
+		a.Processor.IncludeFolders = patterns
+		a.ReloadSubfolders()
 	}
-
-	// Extension editor
-	// Entry for extension
-	a.ExtensionEntry = widget.NewEntry()
-	// Create a horizontal box for the new extension
-	extLabel := widget.NewLabel("Extension:")
-	// Create a radio group for extension operations
-	a.ExtensionRadio = widget.NewRadioGroup([]string{"None", "Change"}, nil)
-	a.ExtensionRadio.Horizontal = true // Make the radio buttons horizontal
-	// Set container for the extension operations
-	a.ExtensionContainer = container.NewBorder(
+	a.ExcludeFoldersEntry = widget.NewEntry()
+	a.ExcludeFoldersEntry.SetPlaceHolder("Skip folders, e.g. .git;thumbs")
+	a.ExcludeFoldersEntry.OnChanged = func(patterns string) {
+		a.Processor.ExcludeFolders = patterns
+		a.ReloadSubfolders()
+	}
+	a.RecursiveCheck = widget.NewCheck("Subfolders", func(checked bool) {
+		a.Processor.Recursive = checked
+		// The depth and folder patterns only apply to subfolders
+		for _, entry := range []*widget.Entry{a.MaxDepthEntry, a.IncludeFoldersEntry, a.ExcludeFoldersEntry} {
+			if checked {
+				entry.Enable()
+			} else {
+				entry.Disable()
+			}
+		}
+		if a.Processor.FolderPath != "" {
+			a.ReloadFolder()
+			a.SchedulePreview()
+		}
+	})
+	a.RecursiveCheck.OnChanged(false)
+	subfolderBox := container.NewBorder(
 		nil, nil,
-		container.NewHBox(extLabel, a.ExtensionRadio),
+		container.NewHBox(a.RecursiveCheck, widget.NewLabel("Max depth:"), a.MaxDepthEntry),
 		nil,
-		a.ExtensionEntry,
+		container.NewGridWithColumns(2, a.IncludeFoldersEntry, a.ExcludeFoldersEntry),
 	)
-	// Set the onChanged function for the extension radio group
-	a.ExtensionRadio.OnChanged = func(selected string) {
-		if selected == "" {
-			a.ExtensionRadio.SetSelected(a.Processor.ExtensionMode)
-			return
-		} // Avoid situation where selected is empty
-		a.Processor.ExtensionMode = selected
-		if selected == "Change" {
-			a.ExtensionEntry.Show()      // Show the entry for changing extension
-			a.ExtensionEntry.SetText("") // Clear the entry text
-			a.Processor.ExtensionValue = ""
-		} else {
-			a.ExtensionEntry.Hide()
-			a.Processor.ExtensionValue = a.ExtensionEntry.Text // Update the extension value in the processor
-		}
-		if a.ExtensionContainer != nil {
-			a.ExtensionContainer.Refresh()
-			a.renameButton.Disable()
-		}
-	}
-	// Set the default selection for suffix radio group
-	a.ExtensionRadio.SetSelected("None")
-	a.ExtensionEntry.SetPlaceHolder("enter ONE Extension, e.g. txt or .txt")
-	// Update value when extension entry changes
-	a.ExtensionEntry.OnChanged = func(value string) {
-		a.Processor.ExtensionValue = value
-		a.renameButton.Disable()
-	}
 
-	// Combine all operation boxes into a vertical box
-	operationsBox := container.NewVBox(
+	// Create operations area
+	// Create a label for operations
+	operationsLabel := widget.NewLabel("Operations:")
+	// Select a step type and append it to the pipeline
+	a.RuleTypeSelect = widget.NewSelect(RuleTypes, nil)
+	a.RuleTypeSelect.SetSelected(RuleTypes[0])
+	addRuleButton := widget.NewButton("Add Step", func() {
+		a.Processor.AddRule(NewRenameRule(a.RuleTypeSelect.Selected))
+		a.RefreshRules()
+	})
+	operationsHeader := container.NewHBox(
 		operationsLabel,
-		a.PrefixContainer,
-		a.SuffixContainer,
-		a.ExtensionContainer,
+		layout.NewSpacer(),
+		a.RuleTypeSelect,
+		addRuleButton,
 	)
-
-	// Create a table to display the original files
-	a.OriginalTable = widget.NewTable(
-		func() (rows int, cols int) {
-			if a.Processor == nil || a.Processor.FilteredFiles == nil {
-				return 0, 1
-			}
-			return len(a.Processor.FilteredFiles), 1
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("wide content")
-		},
-		func(tid widget.TableCellID, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
-			if a.Processor != nil && a.Processor.FilteredFiles != nil && tid.Row < len(a.Processor.FilteredFiles) {
-				label.SetText(a.Processor.FilteredFiles[tid.Row].Name())
-			}
-		},
+	// Rows of the pipeline, rebuilt whenever steps are added, removed or moved
+	a.RulesBox = container.NewVBox()
+	rulesScroll := container.NewVScroll(a.RulesBox)
+	rulesScroll.SetMinSize(fyne.NewSize(0, 150))
+	a.RefreshRules()
+
+	// Choose what happens to files whose new name conflicts with another file
+	a.NumberPatternEntry = widget.NewSelectEntry(NumberPatterns)
+	a.NumberPatternEntry.SetText(a.Processor.NumberPattern)
+	a.NumberPatternEntry.OnChanged = func(pattern string) {
+		a.Processor.NumberPattern = pattern
+		a.SchedulePreview()
+	}
+	a.ConflictSelect = widget.NewSelect(ConflictPolicies, func(selected string) {
+		a.Processor.ConflictPolicy = selected
+		// The pattern is only used to number conflicting names
+		if selected == "Number" {
+			a.NumberPatternEntry.Show()
+		} else {
+			a.NumberPatternEntry.Hide()
+		}
+		a.SchedulePreview()
+	})
+	a.ConflictSelect.SetSelected(a.Processor.ConflictPolicy)
+	// Choose whether a failing rename keeps or reverts the renames already done
+	a.FailureSelect = widget.NewSelect(FailureModes, func(selected string) {
+		a.Processor.FailureMode = selected
+	})
+	a.FailureSelect.SetSelected(a.Processor.FailureMode)
+	conflictRow := container.NewBorder(
+		nil, nil,
+		container.NewHBox(widget.NewLabel("On conflict:"), a.ConflictSelect),
+		container.NewHBox(widget.NewLabel("On error:"), a.FailureSelect),
+		a.NumberPatternEntry,
 	)
 
-	// Create a table to display the processed files
-	a.PreviewTable = widget.NewTable(
-		func() (rows int, cols int) {
-			if a.Processor == nil || a.Processor.NewNames == nil {
-				return 0, 1
-			}
-			return len(a.Processor.NewNames), 1
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("wide content")
-		},
-		func(tid widget.TableCellID, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
-			if a.Processor != nil && a.Processor.NewNames != nil && tid.Row < len(a.Processor.NewNames) {
-				label.SetText(a.Processor.NewNames[tid.Row])
-			}
-		},
+	// Combine the header, the pipeline rows and the conflict policy into one box
+	operationsBox := container.NewBorder(
+		operationsHeader,
+		conflictRow,
+		nil, nil,
+		rulesScroll,
 	)
 
-	// Create container, Set the column width for both tables
-	a.OriginalTable = a.InitializePreviewTable()
-	a.OriginalTable.SetColumnWidth(0, 300)
-	a.OriginalTableContainer = container.NewScroll(a.OriginalTable)
-	a.OriginalTableContainer.SetMinSize(fyne.NewSize(300, 400))
-	a.PreviewTable = a.InitializeOriginalTable()
-	a.PreviewTable.SetColumnWidth(0, 300)
-	a.PreviewTableContainer = container.NewScroll(a.PreviewTable)
-	a.PreviewTableContainer.SetMinSize(fyne.NewSize(300, 400))
+	// Create one table showing each file with its new name, so the rows can't drift apart
+	a.FileTable = a.InitializeFileTable()
+	a.FileTableContainer = container.NewScroll(a.FileTable)
+	a.FileTableContainer.SetMinSize(fyne.NewSize(600, 400))
 	a.ResetTableScroll()
-
-	// Combine the original table and preview table into a horizontal box
-	listsContainer := container.NewGridWithColumns(2,
-		container.NewBorder(
-			widget.NewLabel("Original Files:"),
-			nil, nil, nil,
-			a.OriginalTableContainer,
-		),
-		container.NewBorder(
-			widget.NewLabel("Preview New Names:"),
-			nil, nil, nil,
-			a.PreviewTableContainer,
-		),
+	listsContainer := container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel("Files:"), a.makeSortControls()),
+		nil, nil, nil,
+		a.FileTableContainer,
 	)
 
 	// Create status Lables
@@ -361,6 +342,9 @@ func (a *MainApp) MakeUI() {
 			container.NewVBox(
 				a.FolderPathDisplay,
 				filterBox,
+				nameFilterBox,
+				advancedFilter,
+				subfolderBox,
 				widget.NewSeparator(),
 				operationsBox,
 				widget.NewSeparator(),
@@ -430,16 +414,34 @@ func (a *MainApp) ResetPathScroll() {
 	}
 }
 
-// FilterFiles filters the files based on the specified extension
+// FilterFiles filters the files based on the specified extension, name patterns and regex
 func (a *MainApp) FilterFiles() {
 	a.Processor.FilterFiles()
-	newOriginalTable := a.InitializeOriginalTable()
-	a.OriginalTable = newOriginalTable
-	a.OriginalTableContainer.Content = newOriginalTable
-	a.OriginalTableContainer.Refresh()
+	a.ResetFileTable()
+	if err := a.Processor.ValidateFilters(); err != nil {
+		a.StatusLabel.SetText("Error: " + err.Error())
+		return
+	}
 	a.StatusLabel.SetText(fmt.Sprintf("Filtered: %d files", len(a.Processor.FilteredFiles)))
 }
 
+// Filter the files again after a filter changed and update the preview
+func (a *MainApp) RefreshFilter() {
+	// Clear the new names when filter changes, until the preview of the new files is generated
+	a.Processor.NewNames = nil
+	a.Processor.Statuses = nil
+	a.FilterFiles()
+	a.SchedulePreview()
+}
+
+// Reload the folder after a subfolder setting changed
+func (a *MainApp) ReloadSubfolders() {
+	if a.Processor.Recursive && a.Processor.FolderPath != "" {
+		a.ReloadFolder()
+		a.SchedulePreview()
+	}
+}
+
 // Select a folder and update the PathDisplay
 func (a *MainApp) SelectFolder() {
 	dialog.NewFolderOpen(func(list fyne.ListableURI, err error) {
@@ -467,18 +469,10 @@ func (a *MainApp) SelectFolder() {
 		a.FolderPathLabel.Text.Text = path
 		a.FolderPathLabel.Text.Refresh()
 		a.FolderPathDisplay.Refresh()
-		// Load files into the original table
-		newOriginalTable := a.InitializeOriginalTable()
-		a.OriginalTable = newOriginalTable
-		a.OriginalTableContainer.Content = a.OriginalTable
-		// Load files into the preview table
-		newPreviewTable := a.InitializePreviewTable()
-		a.PreviewTable = newPreviewTable
-		a.Processor.GenerateNewNames()
-		a.PreviewTableContainer.Content = a.PreviewTable
-		a.OriginalTableContainer.Refresh()
-		a.PreviewTableContainer.Refresh()
+		// Show the files at once, their new names follow from the background preview
+		a.ResetTables()
 		a.StatusLabel.SetText(fmt.Sprintf("Loaded %d files", len(a.Processor.Files)))
+		a.livePreview()
 	}, a.Window).Show()
 	a.renameButton.Disable()
 }
@@ -489,24 +483,68 @@ func (a *MainApp) PreviewChanges() {
 		a.StatusLabel.SetText("Select a folder first!")
 		return
 	}
+	// Never offer to rename while a filter is invalid, the files it should leave out are unknown
+	if err := a.Processor.ValidateFilters(); err != nil {
+		a.StatusLabel.SetText("Error: " + err.Error())
+		a.renameButton.Disable()
+		return
+	}
 	// Check if there are any files to rename
 	if len(a.Processor.FilteredFiles) == 0 {
 		a.StatusLabel.SetText("No files to rename!")
 		return
 	}
-	// Get the values from the entries
-	newPreviewTable := a.InitializePreviewTable()
-	a.PreviewTable = newPreviewTable
-	a.PreviewTableContainer.Content = a.PreviewTable
-	a.Processor.PrefixValue = a.PrefixEntry.Text
-	a.Processor.SuffixValue = a.SuffixEntry.Text
-	a.Processor.ExtensionValue = a.ExtensionEntry.Text
-	a.Processor.GenerateNewNames()
-	a.PreviewTable.Refresh()
-	a.PreviewTableContainer.Refresh()
-	a.StatusLabel.SetText(fmt.Sprintf("Preview generated, %d files", len(a.Processor.NewNames)))
+	a.stopPreview()
+	a.showPreview(a.Processor.GenerateNewNames())
+}
+
+// Show the generated names and enable renaming if there are no conflicts
+func (a *MainApp) showPreview(err error) {
+	// Show the new names next to the old ones, the rows and the scroll position stay
+	a.FileTable.Refresh()
+	// Report invalid rules instead of showing broken names
+	if err != nil {
+		a.StatusLabel.SetText("Error: " + err.Error())
+		a.renameButton.Disable()
+		return
+	}
+	// Keep the rename button disabled until all conflicts are resolved
+	if conflicts := a.Processor.ConflictCount(); conflicts > 0 {
+		a.StatusLabel.SetText(fmt.Sprintf("Preview generated, %d files, %d conflicts must be resolved before renaming", len(a.Processor.NewNames), conflicts))
+		a.renameButton.Disable()
+		return
+	}
+	a.StatusLabel.SetText(fmt.Sprintf("Preview generated, %d files", len(a.Processor.NewNames)) + a.PolicySummary())
 	a.renameButton.Enable()
+}
 
+// Describe how many files the conflict policy skipped or numbered, and how many were excluded or edited
+func (a *MainApp) PolicySummary() string {
+	skipped, numbered, excluded := 0, 0, 0
+	for _, status := range a.Processor.Statuses {
+		switch status {
+		case StatusExcluded:
+			excluded++
+		case StatusSkipped:
+			skipped++
+		case StatusResolved:
+			numbered++
+		}
+	}
+	summary := ""
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	if numbered > 0 {
+		summary += fmt.Sprintf(", %d numbered", numbered)
+	}
+	if excluded > 0 {
+		summary += fmt.Sprintf(", %d excluded", excluded)
+	}
+	if edited := a.Processor.OverrideCount(); edited > 0 {
+		summary += fmt.Sprintf(", %d edited by hand", edited)
+	}
+	return summary
 }
 
 // Rename files based on the generated new names
@@ -521,58 +559,85 @@ func (a *MainApp) RunRenameProcess() {
 		return
 	}
 	// Finish the renaming process, check result
+	a.stopPreview()
 	successCount, err := a.Processor.RenameFiles()
+	// Record the executed renames, including those before an error, so they can be undone
+	if journalErr := a.Journal.Append(a.Processor.FolderPath, a.Processor.LastRenames); journalErr != nil {
+		dialog.ShowError(fmt.Errorf("the renames could not be recorded for undo: %w", journalErr), a.Window)
+	}
+	// Continue mode reports every file in the results dialog instead of a single error
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		a.ResetTables()
+		a.StatusLabel.SetText(fmt.Sprintf("Renamed %d files, %d failed", successCount, len(batchErr.Failures)))
+		a.ShowResults()
+		return
+	}
 	if err != nil {
+		// The folder may have changed before the error, show its current state
+		a.ResetTables()
 		a.StatusLabel.SetText("Error: " + err.Error())
+		a.ShowRenameError(err)
 		return
 	}
-	a.OriginalTable.Refresh()
-	newPreviewTable := a.InitializePreviewTable()
-	a.PreviewTable = newPreviewTable
-	a.PreviewTableContainer.Content = newPreviewTable
-	a.PreviewTableContainer.Refresh()
+	// The new names are now the file names, clear them
+	a.ResetTables()
 	a.StatusLabel.SetText(fmt.Sprintf("Successfully renamed %d files", successCount))
 	a.renameButton.Disable()
+	if a.Processor.FailureMode == "Continue" && successCount < len(a.Processor.Results) {
+		a.ShowResults()
+	}
+}
+
+// Explain which file made the batch fail and what happened to the other files
+func (a *MainApp) ShowRenameError(err error) {
+	var renameErr *RenameError
+	if !errors.As(err, &renameErr) {
+		return
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "Failed file: %s\nNew name: %s\nReason: %v\n\n", renameErr.Op.Old, renameErr.Op.New, renameErr.Err)
+	switch {
+	case renameErr.RolledBack:
+		text.WriteString("All renames of this batch were rolled back, the folder is unchanged.")
+	case renameErr.RollbackErr != nil:
+		fmt.Fprintf(&text, "Rollback did not finish: %v\nSome files keep their new name.", renameErr.RollbackErr)
+	default:
+		fmt.Fprintf(&text, "%d files renamed before the error keep their new name.", len(a.Processor.LastRenames))
+	}
+	if len(renameErr.Stranded) > 0 {
+		fmt.Fprintf(&text, "\n\nLeft under a temporary name:\n%s", strings.Join(limitNames(renameErr.Stranded, 20), "\n"))
+	}
+	dialog.ShowInformation("Rename Failed", text.String(), a.Window)
 }
 
 // Clear all content in the table
 func (a *MainApp) ClearAll() {
 	//Reset RenamerProcessor
-	a.Processor = &RenamerProcessor{
-		PrefixMode:    "None",
-		SuffixMode:    "None",
-		ExtensionMode: "None",
-	}
+	a.Processor = NewRenamerProcessor()
 	// Reset PathDisplay
 	a.FolderPathLabel.Text.Text = "No Folder Selected"
 	a.FolderPathLabel.Text.Refresh()
 	a.FolderPathDisplay.Refresh()
 	a.ResetPathScroll()
 	a.FilterEntry.SetText("")
-	// Reset radio buttons
-	a.PrefixRadio.SetSelected("None")
-	a.SuffixRadio.SetSelected("None")
-	a.ExtensionRadio.SetSelected("None")
-	// Reset entries
-	a.PrefixEntry.SetText("")
-	a.PrefixEntry.Hide()
-	a.SuffixEntry.SetText("")
-	a.SuffixEntry.Hide()
-	a.ExtensionEntry.SetText("")
-	a.ExtensionEntry.Hide()
-	// Reset tables
-	a.OriginalTable = a.InitializePreviewTable()
-	a.OriginalTable.Refresh()
-	a.OriginalTableContainer.Content = a.OriginalTable
-	a.PreviewTable = a.InitializeOriginalTable()
-	a.PreviewTable.Refresh()
-	a.PreviewTableContainer.Content = a.PreviewTable
-	// Reset containers
-	a.OriginalTableContainer.Refresh()
-	a.PreviewTableContainer.Refresh()
-	a.PrefixContainer.Refresh()
-	a.SuffixContainer.Refresh()
-	a.ExtensionContainer.Refresh()
+	a.NameFilterEntry.SetText("")
+	a.RegexFilterEntry.SetText("")
+	a.refreshAdvancedFilter()
+	a.TargetSelect.SetSelected(a.Processor.Target)
+	a.RecursiveCheck.SetChecked(false)
+	a.MaxDepthEntry.SetText("")
+	a.IncludeFoldersEntry.SetText("")
+	a.ExcludeFoldersEntry.SetText("")
+	a.refreshSort()
+	// Reset rename pipeline and conflict policy
+	a.RuleTypeSelect.SetSelected(RuleTypes[0])
+	a.RefreshRules()
+	a.ConflictSelect.SetSelected(a.Processor.ConflictPolicy)
+	a.NumberPatternEntry.SetText(a.Processor.NumberPattern)
+	a.FailureSelect.SetSelected(a.Processor.FailureMode)
+	// Reset table
+	a.ResetFileTable()
 	// Reset raname button
 	a.renameButton.Disable()
 	// Update status
@@ -581,71 +646,21 @@ func (a *MainApp) ClearAll() {
 	a.Cleanup()
 }
 
+// Show the current files and new names in a new table
+func (a *MainApp) ResetFileTable() {
+	a.stopPreview()
+	a.FileTable = a.InitializeFileTable()
+	a.FileTableContainer.Content = a.FileTable
+	a.FileTableContainer.Refresh()
+}
+
 // Reset scrollbar of table
 func (a *MainApp) ResetTableScroll() {
-	if a.OriginalTableContainer != nil {
-		a.OriginalTableContainer.ScrollToTop()
-		a.OriginalTableContainer.Offset = fyne.Position{X: 0, Y: 0}
-		a.OriginalTableContainer.Refresh()
+	if a.FileTableContainer != nil {
+		a.FileTableContainer.ScrollToTop()
+		a.FileTableContainer.Offset = fyne.Position{X: 0, Y: 0}
+		a.FileTableContainer.Refresh()
 	}
-	if a.PreviewTableContainer != nil {
-		a.PreviewTableContainer.ScrollToTop()
-		a.PreviewTableContainer.Offset = fyne.Position{X: 0, Y: 0}
-		a.PreviewTableContainer.Refresh()
-	}
-}
-
-// Initialize preview table
-func (a *MainApp) InitializePreviewTable() *widget.Table {
-	table := widget.NewTable(
-		func() (int, int) {
-			if a.Processor == nil || len(a.Processor.NewNames) == 0 {
-				return 0, 1 // Check data in the Processor
-			}
-			return len(a.Processor.NewNames), 1
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("")
-		},
-		func(i widget.TableCellID, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
-			if a.Processor != nil &&
-				len(a.Processor.NewNames) > i.Row {
-				label.SetText(a.Processor.NewNames[i.Row])
-			} else {
-				label.SetText("")
-			}
-		},
-	)
-	table.SetColumnWidth(0, 300)
-	return table
-}
-
-// Initialize original table
-func (a *MainApp) InitializeOriginalTable() *widget.Table {
-	table := widget.NewTable(
-		func() (int, int) {
-			if a.Processor == nil || a.Processor.FilteredFiles == nil {
-				return 0, 1
-			}
-			return len(a.Processor.FilteredFiles), 1
-		},
-		func() fyne.CanvasObject {
-			return widget.NewLabel("")
-		},
-		func(i widget.TableCellID, o fyne.CanvasObject) {
-			label := o.(*widget.Label)
-			if a.Processor != nil &&
-				a.Processor.FilteredFiles != nil &&
-				i.Row < len(a.Processor.FilteredFiles) {
-				label.SetText(a.Processor.FilteredFiles[i.Row].Name())
-			} else {
-				label.SetText("")
-			}
-		},
-	)
-	table.SetColumnWidth(0, 300)
-	return table
 }
 
 // Toggle the theme between light and dark mode
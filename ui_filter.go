@@ -0,0 +1,78 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Create the collapsible panel with the size, date and attribute filters
+func (a *MainApp) makeAdvancedFilter() fyne.CanvasObject {
+	// The processor is looked up on every change, ClearAll replaces it
+	a.MinSizeEntry = a.newSizeEntry("Min, e.g. 10KB", func(size int64) { a.Processor.MinSize = size })
+	a.MaxSizeEntry = a.newSizeEntry("Max, e.g. 20MB", func(size int64) { a.Processor.MaxSize = size })
+	a.AfterEntry = a.newDateEntry("From, e.g. 2024-05-01", false, func(date time.Time) { a.Processor.ModifiedAfter = date })
+	a.BeforeEntry = a.newDateEntry("To, e.g. 2024-05-31", true, func(date time.Time) { a.Processor.ModifiedBefore = date })
+	a.HiddenSelect = widget.NewSelect(AttributeFilters, func(selected string) {
+		a.Processor.HiddenFilter = selected
+		a.RefreshFilter()
+	})
+	a.HiddenSelect.Selected = a.Processor.HiddenFilter
+	a.ReadOnlySelect = widget.NewSelect(AttributeFilters, func(selected string) {
+		a.Processor.ReadOnlyFilter = selected
+		a.RefreshFilter()
+	})
+	a.ReadOnlySelect.Selected = a.Processor.ReadOnlyFilter
+	form := widget.NewForm(
+		widget.NewFormItem("Size", container.NewGridWithColumns(2, a.MinSizeEntry, a.MaxSizeEntry)),
+		widget.NewFormItem("Modified", container.NewGridWithColumns(2, a.AfterEntry, a.BeforeEntry)),
+		widget.NewFormItem("Hidden", container.NewHBox(a.HiddenSelect, widget.NewLabel("Read-only"), a.ReadOnlySelect)),
+	)
+	return widget.NewAccordion(widget.NewAccordionItem("Advanced Filter", form))
+}
+
+// Create an entry for a size limit, the limit is only set while the text is valid
+func (a *MainApp) newSizeEntry(placeHolder string, set func(size int64)) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeHolder)
+	entry.Validator = func(text string) error {
+		_, err := ParseSize(text)
+		return err
+	}
+	entry.OnChanged = func(text string) {
+		if size, err := ParseSize(text); err == nil {
+			set(size)
+			a.RefreshFilter()
+		}
+	}
+	return entry
+}
+
+// Create an entry for a date limit, the limit is only set while the text is valid
+func (a *MainApp) newDateEntry(placeHolder string, end bool, set func(date time.Time)) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeHolder)
+	entry.Validator = func(text string) error {
+		_, err := ParseDate(text, end)
+		return err
+	}
+	entry.OnChanged = func(text string) {
+		if date, err := ParseDate(text, end); err == nil {
+			set(date)
+			a.RefreshFilter()
+		}
+	}
+	return entry
+}
+
+// Show the advanced filter of the processor in the panel
+func (a *MainApp) refreshAdvancedFilter() {
+	a.MinSizeEntry.SetText(FormatSize(a.Processor.MinSize))
+	a.MaxSizeEntry.SetText(FormatSize(a.Processor.MaxSize))
+	a.AfterEntry.SetText(FormatDate(a.Processor.ModifiedAfter, false))
+	a.BeforeEntry.SetText(FormatDate(a.Processor.ModifiedBefore, true))
+	a.HiddenSelect.SetSelected(a.Processor.HiddenFilter)
+	a.ReadOnlySelect.SetSelected(a.Processor.ReadOnlyFilter)
+}
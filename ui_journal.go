@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/dialog"
+)
+
+// Ask for confirmation, then rename the files of the last batch back to their old names
+func (a *MainApp) UndoLastRename() {
+	entry, err := a.Journal.Last()
+	if err != nil {
+		a.StatusLabel.SetText("Error: " + err.Error())
+		return
+	}
+	if entry == nil {
+		a.StatusLabel.SetText("Nothing to undo")
+		return
+	}
+	message := fmt.Sprintf("Rename %d files back to their old names?\n\nFolder: %s\nRenamed: %s",
+		len(entry.Renames), entry.Folder, entry.Time.Format("2006-01-02 15:04:05"))
+	dialog.ShowConfirm("Undo Last Rename", message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		_, report, err := a.Journal.UndoLast(entry.Time)
+		// Reload the folder if it is the one being shown
+		if a.Processor.FolderPath == entry.Folder {
+			a.ReloadFolder()
+		}
+		if err != nil {
+			a.StatusLabel.SetText(fmt.Sprintf("Undo incomplete, %d files restored: %s", report.Restored, err.Error()))
+			a.ShowUndoReport(report)
+			return
+		}
+		a.StatusLabel.SetText(fmt.Sprintf("Undo finished, %d files restored", report.Restored))
+	}, a.Window)
+}
+
+// List the files a partial undo could not restore
+func (a *MainApp) ShowUndoReport(report UndoReport) {
+	if len(report.Missing) == 0 && len(report.Blocked) == 0 {
+		return
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "%d files were restored.\n", report.Restored)
+	if len(report.Missing) > 0 {
+		fmt.Fprintf(&text, "\nNo longer found under their new name:\n%s\n", strings.Join(limitNames(report.Missing, 20), "\n"))
+	}
+	if len(report.Blocked) > 0 {
+		fmt.Fprintf(&text, "\nOld name is now used by another file:\n%s\n", strings.Join(limitNames(report.Blocked, 20), "\n"))
+	}
+	if len(report.Left) > 0 {
+		fmt.Fprintf(&text, "\nThese files stay in the journal, undo again once they are back under their new name.\n")
+	}
+	dialog.ShowInformation("Undo Incomplete", text.String(), a.Window)
+}
+
+// Reload the files of the current folder and reset the table
+func (a *MainApp) ReloadFolder() {
+	if err := a.Processor.LoadFiles(a.Processor.FolderPath); err != nil {
+		a.StatusLabel.SetText("Error loading files: " + err.Error())
+	}
+	a.ResetTables()
+}
+
+// Clear the preview and show the current files in the table
+func (a *MainApp) ResetTables() {
+	a.Processor.NewNames = nil
+	a.Processor.Statuses = nil
+	a.ResetFileTable()
+	a.renameButton.Disable()
+}
+
+// Keep at most limit names, noting how many were left out
+func limitNames(names []string, limit int) []string {
+	if len(names) <= limit {
+		return names
+	}
+	return append(names[:limit:limit], fmt.Sprintf("… and %d more", len(names)-limit))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Type the new name of a file by hand, the name is kept whatever the steps do until it is cleared
+func (a *MainApp) EditNewName(file FileEntry) {
+	// Start from the name shown in the table
+	shown := ""
+	if i := slices.IndexFunc(a.Processor.FilteredFiles, func(f FileEntry) bool { return f.Path() == file.Path() }); i >= 0 && i < len(a.Processor.NewNames) {
+		shown = a.Processor.NewNames[i]
+	}
+	override, overridden := a.Processor.Overrides[file.Path()]
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Leave empty to use the steps")
+	entry.SetText(shown)
+	if overridden {
+		entry.SetText(override)
+	}
+	entry.Validator = func(text string) error {
+		if text != "" && !IsValidFileName(text) {
+			return errors.New("not a valid file name")
+		}
+		return nil
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem("File", widget.NewLabel(file.Path())),
+		widget.NewFormItem("New name", entry),
+	}
+	dialog.ShowForm("Edit New Name", "Save", "Cancel", items, func(confirmed bool) {
+		// An unchanged generated name needs no override
+		if !confirmed || (!overridden && entry.Text == shown) {
+			return
+		}
+		a.Processor.SetOverride(file, entry.Text)
+		a.PreviewChanges()
+	}, a.Window)
+}
+
+// Import the new names of the loaded folder from a CSV or TSV file with old and new names
+func (a *MainApp) ImportMapping() {
+	if a.Processor.FolderPath == "" {
+		a.StatusLabel.SetText("Select a folder first!")
+		return
+	}
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			a.StatusLabel.SetText("Error: " + err.Error())
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+		report, err := a.Processor.ImportMapping(reader)
+		if err != nil {
+			dialog.ShowError(err, a.Window)
+			return
+		}
+		a.FileTable.Refresh() // Show the excluded files
+		a.PreviewChanges()
+		a.StatusLabel.SetText(fmt.Sprintf("Imported %d names: ", report.Imported) + a.StatusLabel.Text)
+		// Tell which rows of the mapping are not used
+		if problems := report.Problems(20); len(problems) > 0 {
+			dialog.ShowInformation("Mapping Imported With Problems", strings.Join(problems, "\n"), a.Window)
+		}
+	}, a.Window)
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".tsv", ".txt"}))
+	openDialog.Show()
+}
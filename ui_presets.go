@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Show the preset menu below the button that opened it
+func (a *MainApp) ShowPresetMenu(button *widget.Button) {
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Load Preset...", a.LoadPreset),
+		fyne.NewMenuItem("Save Preset...", a.SavePreset),
+	)
+	position := fyne.NewPos(0, button.Size().Height)
+	widget.ShowPopUpMenuAtRelativePosition(menu, a.Window.Canvas(), position, button)
+}
+
+// Save the filter, the steps and the conflict settings to a preset file
+func (a *MainApp) SavePreset() {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			a.StatusLabel.SetText("Error: " + err.Error())
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		name := PresetName(writer.URI().Path())
+		if err := WritePreset(writer, a.Processor.Preset(name)); err != nil {
+			dialog.ShowError(fmt.Errorf("saving preset: %w", err), a.Window)
+			return
+		}
+		a.rememberPresetFolder(writer.URI())
+		a.StatusLabel.SetText(fmt.Sprintf("Saved preset %q", name))
+	}, a.Window)
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	saveDialog.SetFileName("preset.json")
+	a.setPresetLocation(saveDialog)
+	saveDialog.Show()
+}
+
+// Load a preset file and show its settings
+func (a *MainApp) LoadPreset() {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			a.StatusLabel.SetText("Error: " + err.Error())
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+		preset, err := ReadPreset(reader)
+		if err != nil {
+			dialog.ShowError(err, a.Window)
+			return
+		}
+		if preset.Name == "" {
+			preset.Name = PresetName(reader.URI().Path())
+		}
+		a.Processor.ApplyPreset(preset)
+		a.RefreshSettings()
+		a.rememberPresetFolder(reader.URI())
+		a.StatusLabel.SetText(fmt.Sprintf("Loaded preset %q, %d steps", preset.Name, len(preset.Rules)))
+	}, a.Window)
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+	a.setPresetLocation(openDialog)
+	openDialog.Show()
+}
+
+// Show the settings of the processor in the filter, pipeline and conflict widgets
+func (a *MainApp) RefreshSettings() {
+	settings := a.Processor.Preset("")
+	a.FilterEntry.SetText(settings.FilterExt) // Refilters the files and clears the preview
+	a.NameFilterEntry.SetText(settings.NameFilter)
+	a.RegexFilterEntry.SetText(settings.RegexFilter)
+	a.refreshAdvancedFilter()
+	a.refreshSort()
+	a.RefreshFilter() // The order may have changed without any filter
+	a.TargetSelect.SetSelected(settings.Target)
+	a.RecursiveCheck.SetChecked(settings.Recursive)
+	a.MaxDepthEntry.SetText("")
+	if settings.MaxDepth > 0 {
+		a.MaxDepthEntry.SetText(strconv.Itoa(settings.MaxDepth))
+	}
+	a.IncludeFoldersEntry.SetText(settings.IncludeFolders)
+	a.ExcludeFoldersEntry.SetText(settings.ExcludeFolders)
+	a.RefreshRules()
+	a.ConflictSelect.SetSelected(settings.ConflictPolicy)
+	a.NumberPatternEntry.SetText(settings.NumberPattern)
+	a.FailureSelect.SetSelected(settings.FailureMode)
+	a.renameButton.Disable()
+}
+
+// Open preset dialogs in the folder used last time
+func (a *MainApp) setPresetLocation(fileDialog *dialog.FileDialog) {
+	folder := a.App.Preferences().String("preset_folder")
+	if folder == "" {
+		return
+	}
+	if location, err := storage.ListerForURI(storage.NewFileURI(folder)); err == nil {
+		fileDialog.SetLocation(location)
+	}
+}
+
+// Remember the folder of a loaded or saved preset
+func (a *MainApp) rememberPresetFolder(uri fyne.URI) {
+	if parent, err := storage.Parent(uri); err == nil {
+		a.App.Preferences().SetString("preset_folder", parent.Path())
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// Time without changes before the preview is generated again, typing a name generates it once
+const previewDelay = 300 * time.Millisecond
+
+// Generate the preview again shortly after a step, filter or conflict setting changed
+func (a *MainApp) SchedulePreview() {
+	a.renameButton.Disable()
+	a.stopPreview()
+	if a.previewTimer != nil {
+		a.previewTimer.Stop()
+	}
+	a.previewTimer = time.AfterFunc(previewDelay, func() {
+		fyne.Do(a.livePreview)
+	})
+}
+
+// Generate the preview in the background if there are files, without asking for a folder like the Preview button.
+// Large folders take a while to check on disk, the window stays responsive and any change stops the run.
+func (a *MainApp) livePreview() {
+	if a.Processor.FolderPath == "" || len(a.Processor.FilteredFiles) == 0 {
+		return
+	}
+	// An invalid filter leaves no files, the Preview button shows its error
+	if a.Processor.ValidateFilters() != nil {
+		a.PreviewChanges()
+		return
+	}
+	a.stopPreview()
+	stop := new(atomic.Bool)
+	a.previewStop = stop
+	preview := a.Processor.previewCopy(stop)
+	a.StatusLabel.SetText("Generating preview...")
+	go func() {
+		err := preview.GenerateNewNames()
+		fyne.Do(func() {
+			// The settings or the files changed since the run started
+			if stop.Load() {
+				return
+			}
+			a.previewStop = nil
+			a.Processor.NewNames = preview.NewNames
+			a.Processor.Statuses = preview.Statuses
+			a.Processor.names = preview.names
+			a.showPreview(err)
+		})
+	}()
+}
+
+// Stop the preview running in the background, its names are thrown away
+func (a *MainApp) stopPreview() {
+	if a.previewStop != nil {
+		a.previewStop.Store(true)
+		a.previewStop = nil
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Show the outcome of every file of the last batch in a table
+func (a *MainApp) ShowResults() {
+	results := a.Processor.Results
+	renamed, skipped, failed := 0, 0, 0
+	for _, result := range results {
+		switch result.Outcome {
+		case "Renamed":
+			renamed++
+		case "Skipped":
+			skipped++
+		case "Failed":
+			failed++
+		}
+	}
+	summary := widget.NewLabel(fmt.Sprintf("Renamed: %d   Skipped: %d   Failed: %d", renamed, skipped, failed))
+	// One row per file: old name, new name, outcome and reason
+	table := widget.NewTable(
+		func() (int, int) {
+			return len(results), 3
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			result := results[i.Row]
+			label.Importance = widget.MediumImportance
+			switch i.Col {
+			case 0:
+				label.SetText(result.Old)
+			case 1:
+				label.SetText(result.New)
+			case 2:
+				text := result.Outcome
+				if result.Reason != "" {
+					text += ": " + result.Reason
+				}
+				if result.Outcome == "Failed" {
+					label.Importance = widget.DangerImportance
+				} else if result.Outcome == "Skipped" {
+					label.Importance = widget.LowImportance
+				}
+				label.SetText(text)
+			}
+		},
+	)
+	table.SetColumnWidth(0, 200)
+	table.SetColumnWidth(1, 200)
+	table.SetColumnWidth(2, 300)
+	content := container.NewBorder(summary, nil, nil, nil, table)
+	resultsDialog := dialog.NewCustom("Rename Results", "Close", content, a.Window)
+	resultsDialog.Resize(fyne.NewSize(760, 480))
+	resultsDialog.Show()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Rebuild the rows of the rename pipeline from the processor's rules and update the preview
+func (a *MainApp) RefreshRules() {
+	a.RulesBox.RemoveAll()
+	if len(a.Processor.Rules) == 0 {
+		a.RulesBox.Add(widget.NewLabel("No steps, add one to start editing names"))
+	}
+	for i := range a.Processor.Rules {
+		a.RulesBox.Add(a.makeRuleRow(i))
+	}
+	a.RulesBox.Refresh()
+	a.SchedulePreview()
+}
+
+// Create the row for one step: its position, editor and move/remove buttons
+func (a *MainApp) makeRuleRow(index int) fyne.CanvasObject {
+	rule := &a.Processor.Rules[index]
+	label := widget.NewLabel(fmt.Sprintf("%d. %s:", index+1, rule.Type))
+	// Buttons to reorder and remove the step
+	upButton := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() {
+		a.Processor.MoveRule(index, -1)
+		a.RefreshRules()
+	})
+	downButton := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() {
+		a.Processor.MoveRule(index, 1)
+		a.RefreshRules()
+	})
+	removeButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		a.Processor.RemoveRule(index)
+		a.RefreshRules()
+	})
+	if index == 0 {
+		upButton.Disable()
+	}
+	if index == len(a.Processor.Rules)-1 {
+		downButton.Disable()
+	}
+	return container.NewBorder(
+		nil, nil,
+		label,
+		container.NewHBox(upButton, downButton, removeButton),
+		a.makeRuleEditor(rule),
+	)
+}
+
+// Create the editor widgets for a step based on its type
+func (a *MainApp) makeRuleEditor(rule *RenameRule) fyne.CanvasObject {
+	switch rule.Type {
+	case "Prefix", "Suffix":
+		entry := a.newRuleEntry(&rule.Value, "enter "+rule.Type+" …")
+		radio := a.newRuleRadio([]string{"Add", "Remove"}, &rule.Mode, nil)
+		return container.NewBorder(nil, nil, radio, nil, entry)
+	case "Extension":
+		entry := a.newRuleEntry(&rule.Value, "enter ONE Extension, e.g. txt or .txt")
+		radio := a.newRuleRadio([]string{"Change", "Remove"}, &rule.Mode, func(selected string) {
+			// The entry is only needed to change the extension
+			if selected == "Remove" {
+				entry.Hide()
+			} else {
+				entry.Show()
+			}
+		})
+		return container.NewBorder(nil, nil, radio, nil, entry)
+	case "Number":
+		radio := a.newRuleRadio([]string{"Prefix", "Suffix", "Replace"}, &rule.Mode, nil)
+		// Counter settings, the numbers follow the order of the files in the table
+		fields := container.NewGridWithColumns(4,
+			labeled("Start", a.newRuleIntEntry(&rule.Start)),
+			labeled("Step", a.newRuleIntEntry(&rule.Step)),
+			labeled("Digits", a.newRuleIntEntry(&rule.Padding)),
+			labeled("Sep.", a.newRuleEntry(&rule.Separator, "")),
+		)
+		return container.NewBorder(nil, nil, radio, nil, fields)
+	case "Template":
+		entry := a.newRuleEntry(&rule.Value, "e.g. {parent}_{mtime:2006-01-02}_{n:04}{ext}")
+		// Show the available tokens and filters
+		helpButton := widget.NewButtonWithIcon("", theme.HelpIcon(), func() {
+			dialog.ShowInformation("Template Tokens", TemplateHelp, a.Window)
+		})
+		counterFields := container.NewHBox(
+			labeled("Start", a.newRuleIntEntry(&rule.Start)),
+			labeled("Step", a.newRuleIntEntry(&rule.Step)),
+		)
+		return container.NewBorder(nil, nil, nil, container.NewHBox(counterFields, helpButton), entry)
+	case "Case":
+		// Small words are only used by Title Case
+		smallWordsEntry := a.newRuleEntry(&rule.Value, "words kept lower case, e.g. of, and")
+		caseSelect := widget.NewSelect(CaseModes, func(selected string) {
+			rule.Mode = selected
+			if selected == "Title" {
+				smallWordsEntry.Show()
+			} else {
+				smallWordsEntry.Hide()
+			}
+			a.SchedulePreview()
+		})
+		caseSelect.SetSelected(rule.Mode)
+		scopeSelect := a.newScopeSelect(rule, []string{"Base name", "Extension", "Full name"})
+		return container.NewBorder(nil, nil, caseSelect, scopeSelect, smallWordsEntry)
+	case "Replace", "Regex":
+		findHint, replaceHint := "find text", "replace with"
+		if rule.Type == "Regex" {
+			findHint, replaceHint = `pattern, e.g. IMG_(\d+)_final`, "replacement, e.g. photo-$1"
+		}
+		findEntry := a.newRuleEntry(&rule.Pattern, findHint)
+		replaceEntry := a.newRuleEntry(&rule.Replacement, replaceHint)
+		// Options for case sensitivity and the part of the name to match
+		ignoreCaseCheck := widget.NewCheck("Ignore case", func(checked bool) {
+			rule.IgnoreCase = checked
+			a.SchedulePreview()
+		})
+		ignoreCaseCheck.SetChecked(rule.IgnoreCase)
+		return container.NewBorder(
+			nil, nil, nil,
+			container.NewHBox(ignoreCaseCheck, a.newScopeSelect(rule, []string{"Base name", "Full name"})),
+			container.NewGridWithColumns(2, findEntry, replaceEntry),
+		)
+	}
+	return widget.NewLabel("Unsupported step")
+}
+
+// Create an entry bound to a text field of a rule
+func (a *MainApp) newRuleEntry(value *string, placeholder string) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder(placeholder)
+	entry.SetText(*value)
+	entry.OnChanged = func(text string) {
+		*value = text
+		a.SchedulePreview()
+	}
+	return entry
+}
+
+// Create an entry bound to a number field of a rule, invalid input keeps the previous number
+func (a *MainApp) newRuleIntEntry(value *int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(*value))
+	entry.Validator = func(text string) error {
+		_, err := strconv.Atoi(text)
+		return err
+	}
+	entry.OnChanged = func(text string) {
+		if number, err := strconv.Atoi(text); err == nil {
+			*value = number
+		}
+		a.SchedulePreview()
+	}
+	return entry
+}
+
+// Put a short label in front of an editor widget
+func labeled(text string, object fyne.CanvasObject) fyne.CanvasObject {
+	return container.NewBorder(nil, nil, widget.NewLabel(text), nil, object)
+}
+
+// Create a horizontal radio group bound to a mode field of a rule
+func (a *MainApp) newRuleRadio(options []string, mode *string, onChanged func(string)) *widget.RadioGroup {
+	radio := widget.NewRadioGroup(options, nil)
+	radio.Horizontal = true // Make the radio buttons horizontal
+	radio.OnChanged = func(selected string) {
+		if selected == "" {
+			radio.SetSelected(*mode)
+			return
+		} // Avoid situation where selected is empty
+		*mode = selected
+		if onChanged != nil {
+			onChanged(selected)
+		}
+		a.SchedulePreview()
+	}
+	radio.SetSelected(*mode)
+	return radio
+}
+
+// Create a select that chooses which part of the name a rule edits
+func (a *MainApp) newScopeSelect(rule *RenameRule, options []string) *widget.Select {
+	scopes := map[string]string{"Base name": "Base", "Extension": "Extension", "Full name": "Full"}
+	scopeSelect := widget.NewSelect(options, func(selected string) {
+		rule.Scope = scopes[selected]
+		a.SchedulePreview()
+	})
+	for _, option := range options {
+		if scopes[option] == rule.Scope {
+			scopeSelect.SetSelected(option)
+		}
+	}
+	if scopeSelect.Selected == "" {
+		scopeSelect.SetSelected(options[0])
+	}
+	return scopeSelect
+}
@@ -0,0 +1,25 @@
+package main
+
+import "fmt"
+
+// Include the file in the batch or leave it untouched
+func (a *MainApp) SetIncluded(file FileEntry, included bool) {
+	a.Processor.SetExcluded(file, !included)
+	a.refreshSelection()
+}
+
+// Exclude all filtered files, or include them all again if none is included
+func (a *MainApp) ToggleAllIncluded() {
+	anyIncluded := a.Processor.IncludedCount() > 0
+	for _, file := range a.Processor.FilteredFiles {
+		a.Processor.SetExcluded(file, anyIncluded)
+	}
+	a.refreshSelection()
+}
+
+// Show the changed selection, the preview is generated again so the new names skip the excluded files
+func (a *MainApp) refreshSelection() {
+	a.FileTable.Refresh()
+	a.SchedulePreview()
+	a.StatusLabel.SetText(fmt.Sprintf("Selected: %d of %d files", a.Processor.IncludedCount(), len(a.Processor.FilteredFiles)))
+}
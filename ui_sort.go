@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Create the sort mode select, the ascending/descending button and the buttons moving the selected file
+func (a *MainApp) makeSortControls() fyne.CanvasObject {
+	a.SortSelect = widget.NewSelect(SortModes, func(selected string) {
+		if selected != a.Processor.SortMode {
+			a.SetSort(selected, a.Processor.SortDescending)
+		}
+	})
+	a.SortSelect.Selected = a.Processor.SortMode
+	a.SortOrderButton = widget.NewButton(sortOrderText(a.Processor.SortDescending), func() {
+		a.SetSort(a.Processor.SortMode, !a.Processor.SortDescending)
+	})
+	a.moveUpButton = widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { a.MoveSelectedFile(-1) })
+	a.moveDownButton = widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { a.MoveSelectedFile(1) })
+	a.refreshSort()
+	return container.NewHBox(widget.NewLabel("Sort:"), a.SortSelect, a.SortOrderButton, a.moveUpButton, a.moveDownButton)
+}
+
+// Change the order of the files, numbering follows the new order once the preview is generated again
+func (a *MainApp) SetSort(mode string, descending bool) {
+	// The manual order starts from the order shown in the table
+	if mode == "Manual" && a.Processor.SortMode != "Manual" {
+		a.Processor.KeepOrder()
+	}
+	a.Processor.SortMode = mode
+	a.Processor.SortDescending = descending
+	a.refreshSort()
+	a.RefreshFilter()
+}
+
+// Move the file selected in the file table up (negative offset) or down (positive offset)
+func (a *MainApp) MoveSelectedFile(offset int) {
+	row := a.selectedRow
+	if row < 0 {
+		a.StatusLabel.SetText("Select a file to move first!")
+		return
+	}
+	if !a.Processor.MoveFile(row, offset) {
+		return
+	}
+	a.refreshSort()
+	a.RefreshFilter()
+	// Keep the moved file selected so it can be moved again
+	a.FileTable.Select(widget.TableCellID{Row: row + offset, Col: 0})
+}
+
+// Sort by the clicked column, clicking the sorted column again reverses the order
+func (a *MainApp) SortByColumn(col int) {
+	if col < 0 || col >= len(fileColumns) {
+		return
+	}
+	if a.sortedColumn() == col {
+		a.SetSort(a.Processor.SortMode, !a.Processor.SortDescending)
+		return
+	}
+	a.SetSort(fileColumns[col].SortMode, false)
+}
+
+// Get the column the files are sorted by, -1 if the sort mode has no column
+func (a *MainApp) sortedColumn() int {
+	mode := a.Processor.SortMode
+	if mode == "Name" {
+		mode = "Natural" // Both name orders belong to the name column
+	}
+	for i, column := range fileColumns {
+		if column.SortMode == mode {
+			return i
+		}
+	}
+	return -1
+}
+
+// Show the sort settings of the processor in the sort widgets
+func (a *MainApp) refreshSort() {
+	a.SortSelect.SetSelected(a.Processor.SortMode)
+	a.SortOrderButton.SetText(sortOrderText(a.Processor.SortDescending))
+	// The manual order is changed by moving files instead of reversing it
+	if a.Processor.SortMode == "Manual" {
+		a.SortOrderButton.Disable()
+	} else {
+		a.SortOrderButton.Enable()
+	}
+}
+
+// Get the header text of a column, the sorted column shows the direction
+func (a *MainApp) headerText(col int) string {
+	if col != a.sortedColumn() {
+		return fileColumns[col].Title
+	}
+	if a.Processor.SortDescending {
+		return fileColumns[col].Title + " ▼"
+	}
+	return fileColumns[col].Title + " ▲"
+}
+
+// Get the label of the ascending/descending button
+func sortOrderText(descending bool) string {
+	if descending {
+		return "▼ Descending"
+	}
+	return "▲ Ascending"
+}
+
+// Format a file size for the table, e.g. "1.5 MB"
+func displaySize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / 1024
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		if value < 1024 {
+			return fmt.Sprintf("%.1f %s", value, unit)
+		}
+		value /= 1024
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
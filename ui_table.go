@@ -0,0 +1,237 @@
+package main
+
+import (
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Columns of the file table and the sort mode a click on their header selects, columns without one can't be sorted
+var fileColumns = []struct {
+	Title    string
+	SortMode string
+	Width    float32
+}{
+	{"✓", "", 40},
+	{"Name", "Natural", 260},
+	{"New Name", "", 260},
+	{"Status", "", 100},
+	{"Size", "Size", 80},
+	{"Modified", "Modified", 130},
+}
+
+// Initialize the file table, a click on a column header sorts the files by that column
+func (a *MainApp) InitializeFileTable() *widget.Table {
+	table := widget.NewTable(
+		func() (int, int) {
+			if a.Processor == nil {
+				return 0, len(fileColumns)
+			}
+			return len(a.Processor.FilteredFiles), len(fileColumns)
+		},
+		func() fyne.CanvasObject {
+			return newFileCell()
+		},
+		a.updateFileCell,
+	)
+	// Remember the selected row for the move buttons, a new table has no selection
+	a.selectedRow = -1
+	table.OnSelected = func(id widget.TableCellID) {
+		a.selectedRow = id.Row
+	}
+	table.OnUnselected = func(id widget.TableCellID) {
+		a.selectedRow = -1
+	}
+	table.ShowHeaderRow = true
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewButton("", nil)
+	}
+	table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		button := o.(*widget.Button)
+		if id.Col < 0 || id.Col >= len(fileColumns) {
+			return
+		}
+		button.SetText(a.headerText(id.Col))
+		// The check column header includes or excludes all files, the others sort if they can
+		switch {
+		case fileColumns[id.Col].Title == "✓":
+			button.OnTapped = a.ToggleAllIncluded
+		case fileColumns[id.Col].SortMode != "":
+			button.OnTapped = func() { a.SortByColumn(id.Col) }
+		default:
+			button.OnTapped = nil
+		}
+	}
+	for col, column := range fileColumns {
+		table.SetColumnWidth(col, column.Width)
+	}
+	return table
+}
+
+// Show one cell of the file table
+func (a *MainApp) updateFileCell(i widget.TableCellID, o fyne.CanvasObject) {
+	cell := o.(*fileCell)
+	label, check, name := cell.label, cell.check, cell.name
+	// The cell catches the taps, a tap selects the row like a tap on the table
+	table := a.FileTable
+	cell.onTapped = func() { table.Select(i) }
+	cell.onDoubleTapped = nil
+	check.OnChanged = nil // Showing the state must not change it
+	check.Hide()
+	name.Hide()
+	label.Show()
+	label.Importance = widget.MediumImportance
+	if a.Processor == nil || i.Row >= len(a.Processor.FilteredFiles) {
+		label.SetText("")
+		return
+	}
+	file := a.Processor.FilteredFiles[i.Row]
+	excluded := a.Processor.Excluded[file.Path()]
+	_, overridden := a.Processor.Overrides[file.Path()]
+	// The new name and status are only known once the preview is generated
+	newName, status := "", RenameStatus("")
+	if i.Row < len(a.Processor.NewNames) && i.Row < len(a.Processor.Statuses) {
+		newName, status = a.Processor.NewNames[i.Row], a.Processor.Statuses[i.Row]
+	}
+	// Grey out the files left out of the batch
+	if excluded {
+		label.Importance = widget.LowImportance
+	}
+	switch fileColumns[i.Col].Title {
+	case "✓":
+		label.Hide()
+		check.Show()
+		check.SetChecked(!excluded)
+		check.OnChanged = func(checked bool) { a.SetIncluded(file, checked) }
+	case "Name":
+		label.SetText(file.Path())
+	case "New Name":
+		// Double-click the new name to type it by hand
+		cell.onDoubleTapped = func() { a.EditNewName(file) }
+		if newName == "" {
+			label.SetText("")
+			return
+		}
+		label.Hide()
+		name.Segments = newNameSegments(file, newName, status)
+		name.Show()
+		name.Refresh()
+	case "Status":
+		label.SetText(string(status))
+		switch {
+		case status.IsConflict():
+			label.Importance = widget.DangerImportance
+			label.SetText("⚠ " + string(status))
+		case status == StatusResolved:
+			label.Importance = widget.WarningImportance
+		case status == StatusOK:
+			label.Importance = widget.SuccessImportance
+		default:
+			label.Importance = widget.LowImportance
+		}
+		// Mark the names typed by hand
+		if overridden && !excluded {
+			label.SetText(label.Text + " ✎")
+		}
+		label.Refresh()
+	case "Size":
+		if file.IsDir() {
+			label.SetText("")
+		} else {
+			label.SetText(displaySize(file.Size()))
+		}
+	case "Modified":
+		label.SetText(file.ModTime().Format("2006-01-02 15:04"))
+	}
+}
+
+// fileCell is a cell of the file table, it shows a label, a check or a highlighted name and reports taps
+type fileCell struct {
+	widget.BaseWidget
+	label *widget.Label
+	check *widget.Check
+	name  *widget.RichText
+
+	onTapped       func()
+	onDoubleTapped func()
+}
+
+// Create an empty cell, only one of its widgets is visible at a time
+func newFileCell() *fileCell {
+	cell := &fileCell{label: widget.NewLabel(""), check: widget.NewCheck("", nil), name: widget.NewRichText()}
+	cell.ExtendBaseWidget(cell)
+	return cell
+}
+
+func (c *fileCell) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(c.label, c.check, c.name))
+}
+
+func (c *fileCell) Tapped(*fyne.PointEvent) {
+	if c.onTapped != nil {
+		c.onTapped()
+	}
+}
+
+func (c *fileCell) DoubleTapped(*fyne.PointEvent) {
+	if c.onDoubleTapped != nil {
+		c.onDoubleTapped()
+	}
+}
+
+// Split the new path into segments, the part of the name that changed is highlighted
+func newNameSegments(file FileEntry, newName string, status RenameStatus) []widget.RichTextSegment {
+	// Files that keep their name, or can't get the new one, are shown without highlight
+	colorName := theme.ColorNameForeground
+	switch {
+	case status.IsConflict():
+		colorName = theme.ColorNameError
+	case status == StatusSkipped || status == StatusExcluded || status == StatusUnchanged:
+		colorName = theme.ColorNameDisabled
+	}
+	head, changed, tail := changedPart(file.Name(), newName)
+	if file.Dir != "" {
+		head = file.Dir + string(filepath.Separator) + head
+	}
+	segment := func(text string, style widget.RichTextStyle) widget.RichTextSegment {
+		style.Inline = true
+		return &widget.TextSegment{Text: text, Style: style}
+	}
+	plain := widget.RichTextStyle{ColorName: colorName}
+	highlight := plain
+	if colorName == theme.ColorNameForeground {
+		highlight.ColorName = theme.ColorNamePrimary
+		if status == StatusResolved {
+			highlight.ColorName = theme.ColorNameWarning
+		}
+		highlight.TextStyle = fyne.TextStyle{Bold: true}
+	}
+	var segments []widget.RichTextSegment
+	for _, part := range []struct {
+		text  string
+		style widget.RichTextStyle
+	}{{head, plain}, {changed, highlight}, {tail, plain}} {
+		if part.text != "" {
+			segments = append(segments, segment(part.text, part.style))
+		}
+	}
+	return segments
+}
+
+// Split the new name into the start it shares with the old name, the changed part and the shared end,
+// e.g. "IMG_01.jpg" → "trip_01.jpg" gives "", "trip", "_01.jpg"
+func changedPart(oldName, newName string) (head, changed, tail string) {
+	oldRunes, newRunes := []rune(oldName), []rune(newName)
+	start := 0
+	for start < len(oldRunes) && start < len(newRunes) && oldRunes[start] == newRunes[start] {
+		start++
+	}
+	end := 0
+	for end < len(oldRunes)-start && end < len(newRunes)-start && oldRunes[len(oldRunes)-1-end] == newRunes[len(newRunes)-1-end] {
+		end++
+	}
+	return string(newRunes[:start]), string(newRunes[start : len(newRunes)-end]), string(newRunes[len(newRunes)-end:])
+}
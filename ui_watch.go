@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Number of lines kept in the watch log
+const watchLogLimit = 1000
+
+// Start watching the selected folder with the current steps, or stop watching
+func (a *MainApp) ToggleWatch() {
+	if a.stopWatch != nil {
+		a.stopWatch()
+		return
+	}
+	if a.Processor.FolderPath == "" {
+		a.StatusLabel.SetText("Select a folder first!")
+		return
+	}
+	if len(a.Processor.Rules) == 0 {
+		a.StatusLabel.SetText("Add steps or load a preset before watching a folder")
+		return
+	}
+	// Later edits of the steps don't change a running watch
+	watcher := NewWatcher(a.Processor.FolderPath, a.Processor.Preset(""), a.logWatch)
+	watcher.Journal = a.Journal
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopWatch = cancel
+	a.watchButton.SetText("Stop Watching")
+	a.StatusLabel.SetText("Watching " + watcher.Folder)
+	a.ShowWatchLog()
+	go func() {
+		err := watcher.Run(ctx)
+		fyne.Do(func() {
+			cancel()
+			a.stopWatch = nil
+			a.watchButton.SetText("Watch Folder")
+			if err != nil {
+				a.logWatch("Error: %v", err)
+				a.StatusLabel.SetText("Error: " + err.Error())
+				return
+			}
+			a.StatusLabel.SetText("Stopped watching " + watcher.Folder)
+			// Show the files renamed while watching
+			if a.Processor.FolderPath == watcher.Folder {
+				a.ReloadFolder()
+			}
+		})
+	}()
+}
+
+// Add a line to the watch log, safe to call from the watcher
+func (a *MainApp) logWatch(format string, args ...any) {
+	line := time.Now().Format("15:04:05 ") + fmt.Sprintf(format, args...)
+	fyne.Do(func() {
+		a.watchLog = append(a.watchLog, line)
+		if len(a.watchLog) > watchLogLimit {
+			a.watchLog = a.watchLog[len(a.watchLog)-watchLogLimit:]
+		}
+		if a.watchLogList != nil {
+			a.watchLogList.Refresh()
+			a.watchLogList.ScrollToBottom()
+		}
+	})
+}
+
+// Show the watch log in its own window, closing the window stops watching
+func (a *MainApp) ShowWatchLog() {
+	if a.watchLogWindow != nil {
+		a.watchLogWindow.RequestFocus()
+		return
+	}
+	a.watchLogList = widget.NewList(
+		func() int {
+			return len(a.watchLog)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(a.watchLog[i])
+		},
+	)
+	window := a.App.NewWindow("Watch Log")
+	window.SetContent(a.watchLogList)
+	window.Resize(fyne.NewSize(500, 400))
+	window.SetOnClosed(func() {
+		a.watchLogWindow = nil
+		a.watchLogList = nil
+		if a.stopWatch != nil {
+			a.stopWatch()
+		}
+	})
+	a.watchLogWindow = window
+	window.Show()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileEntry is a file found in the folder, Dir is its subfolder relative to the folder, empty for the folder itself
+type FileEntry struct {
+	os.FileInfo
+	Dir string
+}
+
+// Get the path of the file relative to the folder
+func (f FileEntry) Path() string {
+	return filepath.Join(f.Dir, f.Name())
+}
+
+// Get the path relative to the folder that the file gets with the new name
+func (f FileEntry) NewPath(newName string) string {
+	return filepath.Join(f.Dir, newName)
+}
+
+// Walk the folder and its subfolders, entering only the folders allowed by the depth and the folder patterns.
+// A folder is listed before its content, renames are ordered deepest-first when they are executed.
+func (rp *RenamerProcessor) walkFiles(root string) ([]FileEntry, error) {
+	var files []FileEntry
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable subfolders are left out, only the folder itself must be readable
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if entry.IsDir() {
+			depth := strings.Count(rel, string(filepath.Separator)) + 1
+			if (rp.MaxDepth > 0 && depth > rp.MaxDepth) || !rp.folderIncluded(entry.Name()) {
+				return filepath.SkipDir
+			}
+		}
+		if !rp.isTarget(entry.IsDir()) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		dir := filepath.Dir(rel)
+		if dir == "." {
+			dir = ""
+		}
+		files = append(files, FileEntry{FileInfo: info, Dir: dir})
+		return nil
+	})
+	return files, err
+}
+
+// Check a subfolder name against the include and exclude patterns, e.g. "2024*;raw". An invalid pattern
+// enters no subfolders.
+func (rp *RenamerProcessor) folderIncluded(name string) bool {
+	if excluded, err := matchesAnyPattern(name, rp.ExcludeFolders); excluded || err != nil {
+		return false
+	}
+	if strings.TrimSpace(rp.IncludeFolders) == "" {
+		return true
+	}
+	included, err := matchesAnyPattern(name, rp.IncludeFolders)
+	return included && err == nil
+}
+
+// Check whether the name matches one of the semicolon separated glob patterns, ignoring case
+func matchesAnyPattern(name, patterns string) (bool, error) {
+	name = strings.ToLower(name)
+	for _, pattern := range strings.Split(patterns, ";") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		matched, err := filepath.Match(pattern, name)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Default time between two scans of a watched folder
+const defaultWatchInterval = 2 * time.Second
+
+// Watcher renames the files that arrive in a folder with the steps of a preset
+type Watcher struct {
+	Folder   string
+	Preset   Preset
+	Interval time.Duration                    // Time between two scans, a file is stable once it is unchanged for one interval
+	Journal  *Journal                         // Records every batch so it can be undone, may be nil
+	Logf     func(format string, args ...any) // Receives a message for every action
+
+	known   map[string]bool        // Files that are already handled or were there before watching started
+	pending map[string]os.FileInfo // New files waiting until their size stops changing
+	count   int                    // Files renamed so far, counters continue from here
+}
+
+// Create a watcher for the folder, the preset's steps are applied to every new file
+func NewWatcher(folder string, preset Preset, logf func(format string, args ...any)) *Watcher {
+	return &Watcher{
+		Folder:   folder,
+		Preset:   preset,
+		Interval: defaultWatchInterval,
+		Logf:     logf,
+	}
+}
+
+// Watch the folder until the context is cancelled, files already in the folder are left alone
+func (w *Watcher) Run(ctx context.Context) error {
+	// Check the steps once, instead of failing for every file
+	if err := w.Preset.Validate(); err != nil {
+		return err
+	}
+	// Only the top of the folder is scanned, subfolders would silently be left alone
+	if w.Preset.Recursive {
+		return fmt.Errorf("watching renames new entries at the top of the folder only, turn off subfolders to watch it")
+	}
+	entries, err := w.readFolder()
+	if err != nil {
+		return err
+	}
+	w.known = make(map[string]bool, len(entries))
+	w.pending = make(map[string]os.FileInfo)
+	for _, info := range entries {
+		w.known[info.Name()] = true
+	}
+	w.Logf("Watching %s, %d existing files and folders are left unchanged", w.Folder, len(entries))
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.Logf("Stopped watching %s", w.Folder)
+			return nil
+		case <-ticker.C:
+			if err := w.scan(); err != nil {
+				w.Logf("Error: %v", err)
+			}
+		}
+	}
+}
+
+// Read the files and folders of the folder, leaving out temporary files of running renames
+func (w *Watcher) readFolder() ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(w.Folder)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.FileInfo
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".batch-renamer-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+	}
+	return files, nil
+}
+
+// Look for new files and rename those that stopped changing since the last scan
+func (w *Watcher) scan() error {
+	files, err := w.readFolder()
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(files))
+	var stable []os.FileInfo
+	for _, info := range files {
+		name := info.Name()
+		present[name] = true
+		if w.known[name] {
+			continue
+		}
+		previous, seen := w.pending[name]
+		if seen && previous.Size() == info.Size() && previous.ModTime().Equal(info.ModTime()) {
+			stable = append(stable, info)
+			delete(w.pending, name)
+			continue
+		}
+		w.pending[name] = info
+	}
+	// Forget files that were removed or renamed by someone else
+	for name := range w.known {
+		if !present[name] {
+			delete(w.known, name)
+		}
+	}
+	for name := range w.pending {
+		if !present[name] {
+			delete(w.pending, name)
+		}
+	}
+	if len(stable) == 0 {
+		return nil
+	}
+	return w.rename(stable)
+}
+
+// Apply the preset to the stable files and rename them as one batch
+func (w *Watcher) rename(files []os.FileInfo) error {
+	rp := NewRenamerProcessor()
+	rp.ApplyPreset(w.Preset)
+	// Counters continue where the previous batch stopped
+	for i := range rp.Rules {
+		rp.Rules[i].Start += w.count * rp.Rules[i].Step
+	}
+	rp.FolderPath = w.Folder
+	// Rename files, folders or both like LoadFiles
+	for _, info := range files {
+		if rp.isTarget(info.IsDir()) {
+			rp.Files = append(rp.Files, FileEntry{FileInfo: info})
+		}
+	}
+	rp.FilterFiles()
+	// Entries left out by the target or the filter are not touched again
+	for _, info := range files {
+		w.known[info.Name()] = true
+	}
+	if len(rp.FilteredFiles) == 0 {
+		return nil
+	}
+	if err := rp.GenerateNewNames(); err != nil {
+		return err
+	}
+	// Leave conflicting files unchanged, the others can still be renamed
+	for i, file := range rp.FilteredFiles {
+		if rp.Statuses[i].IsConflict() || rp.Statuses[i] == StatusSkipped {
+			w.Logf("Left %s unchanged, new name %s conflicts", file.Name(), rp.NewNames[i])
+			rp.Statuses[i] = StatusSkipped
+		}
+	}
+	applied, err := executeRenames(w.Folder, rp.plannedRenames(), rp.FailureMode)
+	for _, op := range applied {
+		w.known[op.New] = true
+		w.Logf("Renamed %s to %s", op.Old, op.New)
+	}
+	w.count += len(applied)
+	if w.Journal != nil {
+		if journalErr := w.Journal.Append(w.Folder, applied); journalErr != nil {
+			err = errors.Join(err, journalErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatchRenamesOnlyTargets(t *testing.T) {
+	folder := t.TempDir()
+	createFiles(t, folder, "new.txt", "album/a.jpg")
+	preset := NewRenamerProcessor().Preset("")
+	preset.Target = "Folders"
+	preset.Rules = []RenameRule{{Type: "Prefix", Mode: "Add", Value: "x_"}}
+	watcher := NewWatcher(folder, preset, t.Logf)
+	watcher.known = make(map[string]bool)
+	var arrived []os.FileInfo
+	for _, name := range []string{"new.txt", "album"} {
+		info, err := os.Lstat(filepath.Join(folder, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		arrived = append(arrived, info)
+	}
+	if err := watcher.rename(arrived); err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, folder, "new.txt", "x_album")
+}
+
+func TestWatchRefusesSubfolders(t *testing.T) {
+	preset := NewRenamerProcessor().Preset("")
+	preset.Recursive = true
+	watcher := NewWatcher(t.TempDir(), preset, t.Logf)
+	if err := watcher.Run(context.Background()); err == nil {
+		t.Error("watching with subfolders started, want an error")
+	}
+}